@@ -0,0 +1,292 @@
+package jlo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrWriterClosed is returned when writing to a closed AsyncWriter
+var ErrWriterClosed = errors.New("writer closed")
+
+// DropPolicy defines how an AsyncWriter handles log entries while its queue is
+// full
+type DropPolicy int
+
+const (
+	// Block waits until the queue has space again
+	Block DropPolicy = iota
+	// DropNewest discards the entry being written
+	DropNewest
+	// DropOldest discards the oldest queued entry to make space
+	DropOldest
+	// DropBelowLevel discards the entry being written if its level is below
+	// AsyncConfig.DropLevel and waits for space otherwise
+	DropBelowLevel
+)
+
+const (
+	// DefaultAsyncQueueSize is the queue size of an AsyncWriter if none is set
+	DefaultAsyncQueueSize = 1024
+	// DefaultDropReportInterval is the interval of dropped entry reports of an
+	// AsyncWriter if none is set
+	DefaultDropReportInterval = 10 * time.Second
+)
+
+// AsyncConfig configures an AsyncWriter
+type AsyncConfig struct {
+	// QueueSize is the maximum number of queued log entries. It defaults to
+	// DefaultAsyncQueueSize.
+	QueueSize int
+	// Policy defines how log entries are handled while the queue is full
+	Policy DropPolicy
+	// DropLevel is the minimum level of log entries which are not dropped by
+	// the DropBelowLevel policy
+	DropLevel LogLevel
+	// DropReportInterval is the interval in which the number of dropped log
+	// entries is logged, if any were dropped. It defaults to
+	// DefaultDropReportInterval.
+	DropReportInterval time.Duration
+	// DropReporter logs the number of dropped log entries. It must not write to
+	// the AsyncWriter itself. It defaults to a logger writing to the output of
+	// the AsyncWriter.
+	DropReporter *Logger
+	// ErrorHandler handles errors of writes to the output destination. It
+	// defaults to the error handler of the DropReporter.
+	ErrorHandler ErrorHandler
+}
+
+// asyncEntry is an encoded log entry waiting in the queue of an AsyncWriter
+type asyncEntry struct {
+	buf   *buffer
+	level LogLevel
+}
+
+// AsyncWriter decouples logging from a slow output destination. Log entries
+// are copied to a bounded queue which is drained by a background goroutine.
+type AsyncWriter struct {
+	out    io.Writer
+	config AsyncConfig
+
+	// mu guards closed and prevents writes to the queue after it is closed
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan asyncEntry
+	flushes chan chan struct{}
+	done    chan struct{}
+
+	dropped      atomic.Uint64
+	totalDropped atomic.Uint64
+	writeErrors  atomic.Uint64
+}
+
+// NewAsyncWriter creates a new async writer writing to out and starts its
+// background goroutine. Close must be called to stop it.
+func NewAsyncWriter(out io.Writer, config AsyncConfig) *AsyncWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultAsyncQueueSize
+	}
+	if config.DropReportInterval <= 0 {
+		config.DropReportInterval = DefaultDropReportInterval
+	}
+	if config.DropReporter == nil {
+		config.DropReporter = NewLogger(out)
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = config.DropReporter.reportError
+	}
+
+	w := &AsyncWriter{
+		out:     out,
+		config:  config,
+		queue:   make(chan asyncEntry, config.QueueSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. It is handled like a log entry of UnknownLevel.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(UnknownLevel, p)
+}
+
+// WriteLevel queues a copy of p according to the drop policy. Levels are passed
+// on to outputs implementing LevelWriter.
+func (w *AsyncWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	buf := getBuffer()
+	buf.b = append(buf.b, p...)
+	e := asyncEntry{buf: buf, level: level}
+
+	switch w.config.Policy {
+	case DropNewest:
+		if !w.tryEnqueue(e) {
+			w.drop(e)
+		}
+	case DropOldest:
+		for !w.tryEnqueue(e) {
+			select {
+			case old := <-w.queue:
+				w.drop(old)
+			default:
+			}
+		}
+	case DropBelowLevel:
+		if level >= w.config.DropLevel {
+			w.queue <- e
+		} else if !w.tryEnqueue(e) {
+			w.drop(e)
+		}
+	default:
+		w.queue <- e
+	}
+
+	return len(p), nil
+}
+
+// tryEnqueue queues the entry if the queue has space
+func (w *AsyncWriter) tryEnqueue(e asyncEntry) bool {
+	select {
+	case w.queue <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// drop discards the log entry and counts it
+func (w *AsyncWriter) drop(e asyncEntry) {
+	putBuffer(e.buf)
+	w.dropped.Add(1)
+	w.totalDropped.Add(1)
+}
+
+// Dropped returns the total number of dropped log entries
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.totalDropped.Load()
+}
+
+// WriteErrors returns the total number of log entries which could not be
+// written to the output destination
+func (w *AsyncWriter) WriteErrors() uint64 {
+	return w.writeErrors.Load()
+}
+
+// Flush waits until all log entries queued before the call are written and
+// flushes the output destination, if it supports it
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+
+	select {
+	case w.flushes <- flushed:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sync flushes the async writer, so that Fatalf and Panicf drain the queue
+// before exiting
+func (w *AsyncWriter) Sync() error {
+	return w.Flush(context.Background())
+}
+
+// Close writes all queued log entries, flushes the output destination and
+// stops the background goroutine. Subsequent writes fail with ErrWriterClosed.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// run writes queued log entries until the queue is closed
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.DropReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-w.queue:
+			if !ok {
+				w.reportDropped()
+				flushWriter(w.out)
+				return
+			}
+			w.write(e)
+		case flushed := <-w.flushes:
+			w.drain()
+			w.reportDropped()
+			flushWriter(w.out)
+			close(flushed)
+		case <-ticker.C:
+			w.reportDropped()
+		}
+	}
+}
+
+// drain writes all currently queued log entries
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case e, ok := <-w.queue:
+			if !ok {
+				return
+			}
+			w.write(e)
+		default:
+			return
+		}
+	}
+}
+
+// write writes the log entry to the output destination, counting and
+// reporting failures
+func (w *AsyncWriter) write(e asyncEntry) {
+	var err error
+	if lw, ok := w.out.(LevelWriter); ok {
+		_, err = lw.WriteLevel(e.level, e.buf.b)
+	} else {
+		_, err = w.out.Write(e.buf.b)
+	}
+	putBuffer(e.buf)
+
+	if err != nil {
+		w.writeErrors.Add(1)
+		w.config.ErrorHandler(fmt.Errorf("async write: %w", err))
+	}
+}
+
+// reportDropped logs the number of log entries dropped since the last report
+func (w *AsyncWriter) reportDropped() {
+	if n := w.dropped.Swap(0); n > 0 {
+		w.config.DropReporter.WithField("@dropped", n).Warnf("dropped %d log entries", n)
+	}
+}
@@ -0,0 +1,296 @@
+package jlo_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedWriter blocks all writes until it is released
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// lines returns the written lines, omitting reports of dropped entries
+func (w *gatedWriter) lines() []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(w.String()), "\n") {
+		if !strings.Contains(line, "@dropped") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func Test_AsyncWriter_Policies(t *testing.T) {
+
+	tests := map[string]struct {
+		Config   jlo.AsyncConfig
+		Overflow []jlo.LogLevel
+		Expected []string
+		Dropped  uint64
+	}{
+		"block": {
+			Config:   jlo.AsyncConfig{Policy: jlo.Block},
+			Overflow: []jlo.LogLevel{jlo.InfoLevel, jlo.InfoLevel},
+			Expected: []string{"0", "1", "2", "3", "4"},
+		},
+		"drop newest": {
+			Config:   jlo.AsyncConfig{Policy: jlo.DropNewest},
+			Overflow: []jlo.LogLevel{jlo.InfoLevel, jlo.ErrorLevel},
+			Expected: []string{"0", "1", "2"},
+			Dropped:  2,
+		},
+		"drop oldest": {
+			Config:   jlo.AsyncConfig{Policy: jlo.DropOldest},
+			Overflow: []jlo.LogLevel{jlo.InfoLevel, jlo.ErrorLevel},
+			Expected: []string{"0", "3", "4"},
+			Dropped:  2,
+		},
+		"drop below level": {
+			Config:   jlo.AsyncConfig{Policy: jlo.DropBelowLevel, DropLevel: jlo.ErrorLevel},
+			Overflow: []jlo.LogLevel{jlo.InfoLevel, jlo.ErrorLevel},
+			Expected: []string{"0", "1", "2", "4"},
+			Dropped:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := newGatedWriter()
+			test.Config.QueueSize = 2
+			w := jlo.NewAsyncWriter(out, test.Config)
+			defer w.Close()
+
+			// the first entry blocks the background goroutine, the next two
+			// fill the queue
+			w.WriteLevel(jlo.InfoLevel, []byte("0\n"))
+			<-out.started
+			w.WriteLevel(jlo.InfoLevel, []byte("1\n"))
+			w.WriteLevel(jlo.InfoLevel, []byte("2\n"))
+
+			written := make(chan struct{})
+			go func() {
+				defer close(written)
+				for i, level := range test.Overflow {
+					w.WriteLevel(level, []byte(fmt.Sprintf("%d\n", i+3)))
+				}
+			}()
+
+			// give blocking writes a chance to complete before releasing the
+			// output
+			select {
+			case <-written:
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(out.release)
+			<-written
+
+			require.NoError(t, w.Flush(context.Background()))
+			assert.Equal(t, test.Expected, out.lines())
+			assert.Equal(t, test.Dropped, w.Dropped())
+		})
+	}
+}
+
+func Test_AsyncWriter_ReportsDropped(t *testing.T) {
+	out := newGatedWriter()
+	w := jlo.NewAsyncWriter(out, jlo.AsyncConfig{QueueSize: 1, Policy: jlo.DropNewest})
+	defer w.Close()
+
+	w.Write([]byte("0\n"))
+	<-out.started
+	w.Write([]byte("1\n"))
+	w.Write([]byte("2\n"))
+	w.Write([]byte("3\n"))
+	close(out.release)
+
+	require.NoError(t, w.Flush(context.Background()))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@dropped":   2,
+		"@level":     "warning",
+		"@message":   "dropped 2 log entries",
+		"@timestamp": "%s"
+	}`, testTime), lines[2])
+
+	// the counter is reset after each report
+	require.NoError(t, w.Flush(context.Background()))
+	assert.Len(t, strings.Split(strings.TrimSpace(out.String()), "\n"), 3)
+}
+
+func Test_AsyncWriter_WriteErrors(t *testing.T) {
+
+	tests := map[string]struct {
+		Config func(errs chan error) jlo.AsyncConfig
+	}{
+		"error handler": {
+			Config: func(errs chan error) jlo.AsyncConfig {
+				return jlo.AsyncConfig{ErrorHandler: func(err error) { errs <- err }}
+			},
+		},
+		"drop reporter error handler": {
+			Config: func(errs chan error) jlo.AsyncConfig {
+				reporter := jlo.NewLogger(bytes.NewBuffer(nil))
+				reporter.SetErrorHandler(func(err error) { errs <- err })
+				return jlo.AsyncConfig{DropReporter: reporter}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := make(chan error, 2)
+			w := jlo.NewAsyncWriter(failingWriter{}, test.Config(errs))
+
+			l := jlo.NewLogger(w)
+			l.Infof("first")
+			l.Infof("second")
+			require.NoError(t, w.Close())
+
+			assert.Equal(t, uint64(2), w.WriteErrors())
+			require.Len(t, errs, 2)
+			assert.EqualError(t, <-errs, "async write: I'm broken")
+		})
+	}
+}
+
+func Test_AsyncWriter_ReportsDroppedPeriodically(t *testing.T) {
+	out := newGatedWriter()
+	report := &lockedBuffer{}
+	w := jlo.NewAsyncWriter(out, jlo.AsyncConfig{
+		QueueSize:          1,
+		Policy:             jlo.DropNewest,
+		DropReportInterval: 10 * time.Millisecond,
+		DropReporter:       jlo.NewLogger(report),
+	})
+	defer w.Close()
+
+	w.Write([]byte("0\n"))
+	<-out.started
+	w.Write([]byte("1\n"))
+	w.Write([]byte("2\n"))
+	close(out.release)
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(report.String(), `"@dropped":1`)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_AsyncWriter_Close(t *testing.T) {
+	buf := &lockedBuffer{}
+	w := jlo.NewAsyncWriter(buf, jlo.AsyncConfig{})
+	l := jlo.NewLogger(w)
+
+	for i := 0; i < 100; i++ {
+		l.Infof("I'm real %d", i)
+	}
+	require.NoError(t, w.Close())
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 100)
+
+	_, err := w.Write([]byte("I'm real\n"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Flush(context.Background()))
+}
+
+func Test_AsyncWriter_Flush_Canceled(t *testing.T) {
+	out := newGatedWriter()
+	w := jlo.NewAsyncWriter(out, jlo.AsyncConfig{})
+	defer w.Close()
+	defer close(out.release)
+
+	w.Write([]byte("0\n"))
+	<-out.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, w.Flush(ctx))
+}
+
+func Test_AsyncWriter_PassesLevels(t *testing.T) {
+	stdout := &lockedBuffer{}
+	stderr := &lockedBuffer{}
+	w := jlo.NewAsyncWriter(jlo.NewRouter(
+		jlo.Route{Writer: stdout, MaxLevel: jlo.WarningLevel},
+		jlo.Route{Writer: stderr, MinLevel: jlo.ErrorLevel},
+	), jlo.AsyncConfig{})
+	l := jlo.NewLogger(w)
+
+	l.Infof("info")
+	l.Errorf("error")
+	require.NoError(t, w.Close())
+
+	assert.Contains(t, stdout.String(), `"@message":"info"`)
+	assert.Contains(t, stderr.String(), `"@message":"error"`)
+	assert.NotContains(t, stdout.String(), `"@message":"error"`)
+}
+
+func Test_Logger_Fatalf_FlushesAsyncWriter(t *testing.T) {
+	buf := &lockedBuffer{}
+	w := jlo.NewAsyncWriter(buf, jlo.AsyncConfig{})
+	defer w.Close()
+	l := jlo.NewLogger(w)
+
+	var written string
+	l.SetExitFunc(func(code int) {
+		written = buf.String()
+	})
+
+	l.Fatalf("I'm real")
+	assert.Contains(t, written, `"@message":"I'm real"`)
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,39 @@
+package jlo
+
+import "sync"
+
+// maxPooledBufferSize limits the capacity of buffers returned to the pool, so a
+// single huge log entry does not pin its memory forever
+const maxPooledBufferSize = 64 << 10
+
+// buffer is a reusable byte slice used to assemble log entries
+type buffer struct {
+	b []byte
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &buffer{b: make([]byte, 0, 1024)}
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *buffer {
+	buf := bufferPool.Get().(*buffer)
+	buf.b = buf.b[:0]
+	return buf
+}
+
+// putBuffer returns the buffer to the pool
+func putBuffer(buf *buffer) {
+	if cap(buf.b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// Write appends p to the buffer, so messages can be formatted directly into it
+func (buf *buffer) Write(p []byte) (int, error) {
+	buf.b = append(buf.b, p...)
+	return len(p), nil
+}
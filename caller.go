@@ -0,0 +1,74 @@
+package jlo
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// callerDepth is the number of stack frames between runtime.Callers in
+// callerPC and the caller of an exported logging method
+const callerDepth = 4
+
+// SetReportCaller enables or disables adding the caller file and line to all
+// log entries
+func (l *Logger) SetReportCaller(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reportCaller = enabled
+}
+
+// SetReportFunction enables or disables adding the caller function name to all
+// log entries
+func (l *Logger) SetReportFunction(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reportFunction = enabled
+}
+
+// WithCallerSkip returns a copy of the logger which skips n additional stack
+// frames when looking up the caller. This allows to report the caller of
+// helper functions wrapping the logger.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	clone := l.clone()
+	clone.callerSkip += n
+	return clone
+}
+
+// callerPC returns the program counter of the stack frame skip frames above the
+// caller of callerPC
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) < 1 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// addCaller adds the caller fields for the program counter to the entry
+func (l *Logger) addCaller(e *EntryView, pc uintptr) {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	if l.reportCaller {
+		e.addField(FieldKeyCaller, shortCaller(frame.File, frame.Line))
+	}
+	if l.reportFunction {
+		e.addField(FieldKeyFunction, frame.Function)
+	}
+}
+
+// shortCaller formats file and line as `dir/file.go:123` with only the last
+// directory of the file path kept
+func shortCaller(file string, line int) string {
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(line)
+}
@@ -0,0 +1,115 @@
+package jlo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextLineCaller returns the expected caller field for the line following the
+// call of nextLineCaller
+func nextLineCaller() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(file)), filepath.Base(file), line+1)
+}
+
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	var entry map[string]interface{}
+	require.NoError(t, json.NewDecoder(buf).Decode(&entry))
+	return entry
+}
+
+func Test_Logger_SetReportCaller(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetLogLevel(jlo.DebugLevel)
+	l.SetReportCaller(true)
+	l.SetExitFunc(func(int) {})
+
+	caller := nextLineCaller()
+	l.Debugf("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	l.Infof("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	l.Warnf("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	l.Errorf("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	l.Fatalf("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	assert.Panics(t, func() { l.Panicf("I'm real") })
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	l.WithField("@request_id", "e44c2a9").Infof("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+}
+
+func Test_Logger_SetReportCaller_Disabled(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+
+	l.Infof("I'm real")
+	entry := decodeEntry(t, buf)
+	assert.NotContains(t, entry, "@caller")
+	assert.NotContains(t, entry, "@function")
+}
+
+func Test_Logger_SetReportFunction(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetReportFunction(true)
+
+	l.Infof("I'm real")
+	entry := decodeEntry(t, buf)
+	assert.NotContains(t, entry, "@caller")
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.Test_Logger_SetReportFunction", entry["@function"])
+}
+
+// logHelper is a wrapper around a logger as commonly built by users
+func logHelper(l *jlo.Logger, msg string) {
+	l.WithCallerSkip(1).Infof(msg)
+}
+
+func Test_Logger_WithCallerSkip(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetReportCaller(true)
+	l.SetReportFunction(true)
+
+	caller := nextLineCaller()
+	logHelper(l, "I'm real")
+
+	entry := decodeEntry(t, buf)
+	assert.Equal(t, caller, entry["@caller"])
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.Test_Logger_WithCallerSkip", entry["@function"])
+}
+
+func Test_SlogHandler_ReportCaller(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetReportCaller(true)
+
+	caller := nextLineCaller()
+	slog.New(jlo.NewSlogHandler(l)).Info("I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+}
@@ -0,0 +1,126 @@
+package jlo
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the time of log entries
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts an ordinary function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now returns f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SetClock changes the clock providing the time of log entries. A nil clock
+// restores the default, which is the package-level Now function.
+func (l *Logger) SetClock(clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clock = clock
+}
+
+// SetTimePrecision changes the precision of log entry timestamps, which are
+// truncated to a multiple of precision. Zero or negative values disable
+// truncation.
+func (l *Logger) SetTimePrecision(precision time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timePrecision = precision
+}
+
+// SetTimeLocation changes the time zone of log entry timestamps. A nil
+// location keeps the time zone of the clock, which is UTC by default.
+func (l *Logger) SetTimeLocation(loc *time.Location) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timeLocation = loc
+}
+
+// now returns the current time of the logger clock adjusted to the configured
+// precision and time zone
+func (l *Logger) now() time.Time {
+	var t time.Time
+	if l.clock != nil {
+		t = l.clock.Now()
+	} else {
+		t = Now()
+	}
+
+	if l.timePrecision > 0 {
+		t = t.Truncate(l.timePrecision)
+	}
+	if l.timeLocation != nil {
+		t = t.In(l.timeLocation)
+	}
+	return t
+}
+
+// FrozenClock is a Clock which returns the same time until it is changed,
+// intended for tests
+type FrozenClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFrozenClock creates a new clock frozen at t
+func NewFrozenClock(t time.Time) *FrozenClock {
+	return &FrozenClock{t: t}
+}
+
+// Now returns the frozen time
+func (c *FrozenClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
+
+// Set freezes the clock at t
+func (c *FrozenClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.t = t
+}
+
+// Add moves the frozen time by d
+func (c *FrozenClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+// SteppingClock is a Clock which advances by a fixed step every time it is
+// read, intended for tests
+type SteppingClock struct {
+	mu   sync.Mutex
+	next time.Time
+	step time.Duration
+}
+
+// NewSteppingClock creates a new clock returning start first and advancing by
+// step on every subsequent call
+func NewSteppingClock(start time.Time, step time.Duration) *SteppingClock {
+	return &SteppingClock{next: start, step: step}
+}
+
+// Now returns the current time of the clock and advances it by one step
+func (c *SteppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := c.next
+	c.next = c.next.Add(c.step)
+	return t
+}
@@ -0,0 +1,131 @@
+package jlo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	clockTestTime  = time.Date(2020, 2, 29, 13, 37, 42, 123456789, time.UTC)
+	clockTestTime2 = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func Test_Logger_SetClock(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	clock := jlo.NewFrozenClock(clockTestTime)
+	l.SetClock(clock)
+
+	l.Infof("I'm real")
+	assert.JSONEq(t, `{
+		"@message":   "I'm real",
+		"@level":     "info",
+		"@timestamp": "2020-02-29T13:37:42.123456789Z"
+	}`, buf.String())
+
+	// check that clones inherit the clock
+	buf.Reset()
+	clock.Add(time.Hour)
+	l.WithField("@request_id", "e44c2a9").Infof("I'm real")
+	assert.JSONEq(t, `{
+		"@message":    "I'm real",
+		"@level":      "info",
+		"@request_id": "e44c2a9",
+		"@timestamp":  "2020-02-29T14:37:42.123456789Z"
+	}`, buf.String())
+
+	// check that the package-level clock is used again after reset
+	buf.Reset()
+	l.SetClock(nil)
+	l.Infof("I'm real")
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":   "I'm real",
+		"@level":     "info",
+		"@timestamp": "%s"
+	}`, testTime), buf.String())
+}
+
+func Test_Logger_SetClock_ClockFunc(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetClock(jlo.ClockFunc(func() time.Time {
+		return clockTestTime
+	}))
+
+	l.Infof("I'm real")
+	assert.Contains(t, buf.String(), `"@timestamp":"2020-02-29T13:37:42.123456789Z"`)
+}
+
+func Test_SteppingClock(t *testing.T) {
+	t.Parallel()
+
+	clock := jlo.NewSteppingClock(clockTestTime, time.Millisecond)
+
+	assert.Equal(t, clockTestTime, clock.Now())
+	assert.Equal(t, clockTestTime.Add(time.Millisecond), clock.Now())
+	assert.Equal(t, clockTestTime.Add(2*time.Millisecond), clock.Now())
+}
+
+func Test_FrozenClock(t *testing.T) {
+	t.Parallel()
+
+	clock := jlo.NewFrozenClock(clockTestTime)
+	assert.Equal(t, clockTestTime, clock.Now())
+	assert.Equal(t, clockTestTime, clock.Now())
+
+	clock.Add(time.Second)
+	assert.Equal(t, clockTestTime.Add(time.Second), clock.Now())
+
+	clock.Set(clockTestTime2)
+	assert.Equal(t, clockTestTime2, clock.Now())
+}
+
+func Test_Logger_SetTimePrecision(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		Precision time.Duration
+		Timestamp string
+	}{
+		"none":        {Precision: 0, Timestamp: "2020-02-29T13:37:42.123456789Z"},
+		"millisecond": {Precision: time.Millisecond, Timestamp: "2020-02-29T13:37:42.123Z"},
+		"second":      {Precision: time.Second, Timestamp: "2020-02-29T13:37:42Z"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetClock(jlo.NewFrozenClock(clockTestTime))
+			l.SetTimePrecision(test.Precision)
+
+			l.Infof("I'm real")
+			assert.Contains(t, buf.String(), `"@timestamp":"`+test.Timestamp+`"`)
+		})
+	}
+}
+
+func Test_Logger_SetTimeLocation(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetClock(jlo.NewFrozenClock(clockTestTime))
+	l.SetTimeLocation(time.FixedZone("CET", 60*60))
+
+	l.Infof("I'm real")
+	assert.Contains(t, buf.String(), `"@timestamp":"2020-02-29T14:37:42.123456789+01:00"`)
+}
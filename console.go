@@ -0,0 +1,186 @@
+package jlo
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultConsoleTimeFormat is the time format used by ConsoleEncoder if none is
+// set
+const DefaultConsoleTimeFormat = "15:04:05.000"
+
+// ANSI escape codes used by ConsoleEncoder
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// ConsoleEncoder encodes log entries in a human-friendly format intended for
+// local development, like
+//
+//	15:04:05.000 INF message key=value
+//
+// Continuation lines of multiline messages and fields are indented below the
+// first line.
+type ConsoleEncoder struct {
+	// Color enables ANSI colors
+	Color bool
+	// TimeFormat is the layout of the timestamp, see time.Time.Format. It
+	// defaults to DefaultConsoleTimeFormat.
+	TimeFormat string
+}
+
+// WithConsoleEncoder returns an option which makes the logger use a
+// ConsoleEncoder. Colors are enabled if the output is a terminal and the
+// NO_COLOR environment variable is not set.
+func WithConsoleEncoder() Option {
+	return func(l *Logger) {
+		l.encoder = ConsoleEncoder{
+			Color: isTerminal(l.out) && os.Getenv("NO_COLOR") == "",
+		}
+	}
+}
+
+// isTerminal reports whether w is a character device like a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleIndent is the indentation of continuation lines, aligned with the
+// message after the timestamp and level tag
+const consoleIndent = "    "
+
+// AppendEntry implements Encoder
+func (enc ConsoleEncoder) AppendEntry(dst []byte, e *EntryView) []byte {
+	timeFormat := enc.TimeFormat
+	if timeFormat == "" {
+		timeFormat = DefaultConsoleTimeFormat
+	}
+
+	dst = enc.appendColored(dst, colorGray, func(dst []byte) []byte {
+		return e.time.AppendFormat(dst, timeFormat)
+	})
+	dst = append(dst, ' ')
+	dst = enc.appendColored(dst, consoleLevelColor(e.level), func(dst []byte) []byte {
+		return append(dst, consoleLevelTag(e.level)...)
+	})
+	dst = append(dst, ' ')
+
+	msg := string(e.msg.b)
+	firstLine, moreLines := msg, ""
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		firstLine, moreLines = msg[:i], msg[i+1:]
+	}
+	dst = append(dst, firstLine...)
+
+	// single line fields are written on the first line, multiline fields like
+	// stack traces are written below the message
+	var multiline []*field
+	fs := e.customFields()
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		if s, ok := f.value.(string); ok && strings.IndexByte(s, '\n') >= 0 {
+			multiline = append(multiline, f)
+			fs.next()
+			continue
+		}
+
+		dst = append(dst, ' ')
+		dst = enc.appendKey(dst, f.key)
+		dst = appendLogfmtValue(dst, f.value)
+		fs.next()
+	}
+
+	if moreLines != "" {
+		dst = appendConsoleLines(dst, moreLines, consoleIndent)
+	}
+	for _, f := range multiline {
+		dst = append(dst, '\n')
+		dst = append(dst, consoleIndent...)
+		dst = enc.appendKey(dst, f.key)
+		dst = appendConsoleLines(dst, f.value.(string), consoleIndent+consoleIndent)
+	}
+
+	return dst
+}
+
+// appendKey appends the key followed by an equal sign
+func (enc ConsoleEncoder) appendKey(dst []byte, key string) []byte {
+	return enc.appendColored(dst, colorCyan, func(dst []byte) []byte {
+		first := true
+		return appendLogfmtKey(dst, key, &first)
+	})
+}
+
+// appendColored appends the output of fn wrapped in the given color, if colors
+// are enabled
+func (enc ConsoleEncoder) appendColored(dst []byte, color string, fn func([]byte) []byte) []byte {
+	if !enc.Color {
+		return fn(dst)
+	}
+
+	dst = append(dst, color...)
+	dst = fn(dst)
+	return append(dst, colorReset...)
+}
+
+// appendConsoleLines appends every line of s on its own indented line
+func appendConsoleLines(dst []byte, s, indent string) []byte {
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		dst = append(dst, '\n')
+		dst = append(dst, indent...)
+		dst = append(dst, line...)
+	}
+	return dst
+}
+
+// consoleLevelTag returns the three letter tag of the log level
+func consoleLevelTag(level LogLevel) string {
+	switch level {
+	case DebugLevel:
+		return "DBG"
+	case InfoLevel:
+		return "INF"
+	case WarningLevel:
+		return "WRN"
+	case ErrorLevel:
+		return "ERR"
+	case FatalLevel:
+		return "FTL"
+	case PanicLevel:
+		return "PNC"
+	default:
+		return "???"
+	}
+}
+
+// consoleLevelColor returns the ANSI color of the log level
+func consoleLevelColor(level LogLevel) string {
+	switch level {
+	case DebugLevel:
+		return colorMagenta
+	case InfoLevel:
+		return colorGreen
+	case WarningLevel:
+		return colorYellow
+	case ErrorLevel:
+		return colorRed
+	default:
+		return colorBold + colorRed
+	}
+}
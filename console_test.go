@@ -0,0 +1,114 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConsoleEncoder(t *testing.T) {
+
+	tests := map[string]struct {
+		Color    bool
+		Logger   func(l *jlo.Logger) *jlo.Logger
+		Log      func(l *jlo.Logger)
+		Expected string
+	}{
+		"simple": {
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: "21:48:56.856 INF I'm real",
+		},
+		"levels": {
+			Log: func(l *jlo.Logger) {
+				l.Debugf("I'm real")
+				l.Warnf("I'm real")
+				l.Errorf("I'm real")
+			},
+			Expected: "21:48:56.856 DBG I'm real\n" +
+				"21:48:56.856 WRN I'm real\n" +
+				"21:48:56.856 ERR I'm real",
+		},
+		"fields": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithFields(jlo.Entry{
+					"@request_id": "e44c2a9",
+					"count":       42,
+					"error":       errors.New("I'm real"),
+				})
+			},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: `21:48:56.856 INF I'm real @request_id=e44c2a9 count=42 error="I'm real"`,
+		},
+		"multiline message": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("count", 42)
+			},
+			Log: func(l *jlo.Logger) { l.Infof("I'm\nreal\n") },
+			Expected: "21:48:56.856 INF I'm count=42\n" +
+				"    real",
+		},
+		"multiline field": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("@stacktrace", "main.main\n\tmain.go:42").WithField("count", 42)
+			},
+			Log: func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: "21:48:56.856 INF I'm real count=42\n" +
+				"    @stacktrace=\n" +
+				"        main.main\n" +
+				"        \tmain.go:42",
+		},
+		"colors": {
+			Color: true,
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("count", 42)
+			},
+			Log: func(l *jlo.Logger) {
+				l.Infof("I'm real")
+				l.Errorf("I'm real")
+			},
+			Expected: "\x1b[90m21:48:56.856\x1b[0m \x1b[32mINF\x1b[0m I'm real \x1b[36mcount=\x1b[0m42\n" +
+				"\x1b[90m21:48:56.856\x1b[0m \x1b[31mERR\x1b[0m I'm real \x1b[36mcount=\x1b[0m42",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf, jlo.WithEncoder(jlo.ConsoleEncoder{Color: test.Color}))
+			l.SetLogLevel(jlo.DebugLevel)
+			if test.Logger != nil {
+				l = test.Logger(l)
+			}
+
+			test.Log(l)
+			assert.Equal(t, test.Expected+"\n", buf.String())
+		})
+	}
+}
+
+func Test_ConsoleEncoder_TimeFormat(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf, jlo.WithEncoder(jlo.ConsoleEncoder{TimeFormat: "2006-01-02 15:04:05"}))
+
+	l.Infof("I'm real")
+	assert.Equal(t, "2018-08-02 21:48:56 INF I'm real\n", buf.String())
+}
+
+func Test_WithConsoleEncoder_NoColorWithoutTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	require.NoError(t, err)
+	defer f.Close()
+
+	l := jlo.NewLogger(f, jlo.WithConsoleEncoder())
+	l.Infof("I'm real")
+
+	b, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "21:48:56.856 INF I'm real\n", string(b))
+}
@@ -0,0 +1,153 @@
+package jlo
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// contextKey is the key of the logger stored in a context
+type contextKey struct{}
+
+// defaultContextLogger is returned by FromContext for contexts without a logger
+var defaultContextLogger atomic.Pointer[Logger]
+
+// NewContext returns a copy of ctx carrying the logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx. If there is none, the logger
+// set with SetContextDefault is returned, which defaults to a logger writing to
+// stdout.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok && l != nil {
+		return l
+	}
+
+	if l := defaultContextLogger.Load(); l != nil {
+		return l
+	}
+	defaultContextLogger.CompareAndSwap(nil, DefaultLogger())
+	return defaultContextLogger.Load()
+}
+
+// SetContextDefault changes the logger FromContext returns for contexts without
+// a logger. A nil logger restores the default, which writes to stdout.
+func SetContextDefault(l *Logger) {
+	defaultContextLogger.Store(l)
+}
+
+// ContextExtractor returns the fields to add to entries logged with a context,
+// e.g. a request id stored in the context. It may return nil if the context
+// holds no such values.
+type ContextExtractor func(ctx context.Context) Entry
+
+// ContextValueExtractor returns an extractor which adds the context value of
+// ctxKey as field key, if the context holds a value for ctxKey
+func ContextValueExtractor(key string, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context) Entry {
+		v := ctx.Value(ctxKey)
+		if v == nil {
+			return nil
+		}
+		return Entry{key: v}
+	}
+}
+
+// WithContextExtractors returns an option which adds the context extractors to
+// the logger
+func WithContextExtractors(extractors ...ContextExtractor) Option {
+	return func(l *Logger) {
+		l.contextExtractors = append(l.contextExtractors, extractors...)
+	}
+}
+
+// AddContextExtractor adds a context extractor to the logger, which is called
+// for every entry logged with one of the context methods like InfoContext.
+// Loggers created with WithField and the like inherit the extractors of the
+// logger at the time of their creation.
+func (l *Logger) AddContextExtractor(fn ContextExtractor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// copy on append, as the extractors are shared with clones
+	l.contextExtractors = append(l.contextExtractors[:len(l.contextExtractors):len(l.contextExtractors)], fn)
+}
+
+// addContextFields adds the fields extracted from ctx to the entry. They take
+// precedence over the logger fields of the same name.
+func (l *Logger) addContextFields(ctx context.Context, e *EntryView) {
+	for _, extract := range l.contextExtractors {
+		for key, value := range extract(ctx) {
+			e.SetField(key, value)
+		}
+	}
+}
+
+// PanicContext logs a message on PanicLevel with the fields extracted from ctx
+// and panics afterwards with the formatted message
+func (l *Logger) PanicContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	l.log(ctx, PanicLevel, format, args...)
+	l.flush()
+
+	if len(args) > 0 {
+		panic(fmt.Sprintf(format, args...))
+	}
+	panic(format)
+}
+
+// FatalContext logs a message on FatalLevel with the fields extracted from
+// ctx, flushes the output and terminates the program by calling the exit
+// function
+func (l *Logger) FatalContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	l.log(ctx, FatalLevel, format, args...)
+	l.flush()
+	l.exit(1)
+}
+
+// ErrorContext logs a message on ErrorLevel with the fields extracted from ctx
+func (l *Logger) ErrorContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.logLevel <= ErrorLevel {
+		l.log(ctx, ErrorLevel, format, args...)
+	}
+}
+
+// WarnContext logs a message on WarningLevel with the fields extracted from ctx
+func (l *Logger) WarnContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.logLevel <= WarningLevel {
+		l.log(ctx, WarningLevel, format, args...)
+	}
+}
+
+// InfoContext logs a message on InfoLevel with the fields extracted from ctx
+func (l *Logger) InfoContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.logLevel <= InfoLevel {
+		l.log(ctx, InfoLevel, format, args...)
+	}
+}
+
+// DebugContext logs a message on DebugLevel with the fields extracted from ctx
+func (l *Logger) DebugContext(ctx context.Context, format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.logLevel <= DebugLevel {
+		l.log(ctx, DebugLevel, format, args...)
+	}
+}
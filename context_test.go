@@ -0,0 +1,146 @@
+package jlo_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+type tenantKey struct{}
+
+func Test_FromContext(t *testing.T) {
+	l := jlo.NewLogger(bytes.NewBuffer(nil))
+	ctx := jlo.NewContext(context.Background(), l)
+	assert.Same(t, l, jlo.FromContext(ctx))
+}
+
+func Test_FromContext_Default(t *testing.T) {
+	assert.NotNil(t, jlo.FromContext(context.Background()))
+	assert.Same(t, jlo.FromContext(context.Background()), jlo.FromContext(context.Background()))
+
+	l := jlo.NewLogger(bytes.NewBuffer(nil))
+	jlo.SetContextDefault(l)
+	defer jlo.SetContextDefault(nil)
+
+	assert.Same(t, l, jlo.FromContext(context.Background()))
+}
+
+func Test_Logger_InfoContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "e44c2a9")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	tests := map[string]struct {
+		Extractors []jlo.ContextExtractor
+		Fields     jlo.Entry
+		Context    context.Context
+		Expected   string
+	}{
+		"no extractors": {
+			Context:  ctx,
+			Expected: `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+		"extractors": {
+			Extractors: []jlo.ContextExtractor{
+				jlo.ContextValueExtractor("@request_id", requestIDKey{}),
+				func(ctx context.Context) jlo.Entry {
+					return jlo.Entry{"tenant": ctx.Value(tenantKey{}), "@user_id": 42}
+				},
+			},
+			Context:  ctx,
+			Expected: `{"@level":"info","@message":"I'm real","@request_id":"e44c2a9","@timestamp":"2018-08-02T21:48:56.856339554Z","@user_id":42,"tenant":"acme"}`,
+		},
+		"missing value": {
+			Extractors: []jlo.ContextExtractor{jlo.ContextValueExtractor("@request_id", requestIDKey{})},
+			Context:    context.Background(),
+			Expected:   `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+		"overwrites logger fields": {
+			Extractors: []jlo.ContextExtractor{jlo.ContextValueExtractor("@request_id", requestIDKey{})},
+			Fields:     jlo.Entry{"@request_id": "overwritten", "service": "api"},
+			Context:    ctx,
+			Expected:   `{"@level":"info","@message":"I'm real","@request_id":"e44c2a9","@timestamp":"2018-08-02T21:48:56.856339554Z","service":"api"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf, jlo.WithContextExtractors(test.Extractors...))
+			if test.Fields != nil {
+				l = l.WithFields(test.Fields)
+			}
+
+			l.InfoContext(test.Context, "I'm %s", "real")
+			assert.Equal(t, test.Expected+"\n", buf.String())
+		})
+	}
+}
+
+func Test_Logger_AddContextExtractor_InheritedByClones(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "e44c2a9")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.AddContextExtractor(jlo.ContextValueExtractor("@request_id", requestIDKey{}))
+	child := l.WithField("service", "api")
+	l.AddContextExtractor(jlo.ContextValueExtractor("tenant", tenantKey{}))
+
+	child.InfoContext(ctx, "I'm real")
+	assert.Equal(t, `{"@level":"info","@message":"I'm real","@request_id":"e44c2a9","@timestamp":"2018-08-02T21:48:56.856339554Z","service":"api"}`+"\n", buf.String())
+}
+
+func Test_Logger_Context_Levels(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "e44c2a9")
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf, jlo.WithContextExtractors(jlo.ContextValueExtractor("@request_id", requestIDKey{})))
+	l.SetLogLevel(jlo.DebugLevel)
+	l.SetReportCaller(true)
+	l.SetExitFunc(func(int) {})
+
+	tests := []struct {
+		Level string
+		Log   func()
+	}{
+		{"debug", func() { l.DebugContext(ctx, "I'm real") }},
+		{"info", func() { l.InfoContext(ctx, "I'm real") }},
+		{"warning", func() { l.WarnContext(ctx, "I'm real") }},
+		{"error", func() { l.ErrorContext(ctx, "I'm real") }},
+		{"fatal", func() { l.FatalContext(ctx, "I'm real") }},
+	}
+
+	for _, test := range tests {
+		test.Log()
+		entry := decodeEntry(t, buf)
+		assert.Equal(t, test.Level, entry["@level"])
+		assert.Equal(t, "e44c2a9", entry["@request_id"])
+	}
+
+	caller := nextLineCaller()
+	l.InfoContext(ctx, "I'm real")
+	assert.Equal(t, caller, decodeEntry(t, buf)["@caller"])
+
+	caller = nextLineCaller()
+	assert.PanicsWithValue(t, "I'm real", func() { l.PanicContext(ctx, "I'm real") })
+	entry := decodeEntry(t, buf)
+	assert.Equal(t, caller, entry["@caller"])
+	assert.Equal(t, "e44c2a9", entry["@request_id"])
+}
+
+func Test_SlogHandler_Handle_Context(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "e44c2a9")
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf, jlo.WithContextExtractors(jlo.ContextValueExtractor("@request_id", requestIDKey{})))
+
+	slog.New(jlo.NewSlogHandler(l)).InfoContext(ctx, "I'm real")
+	assert.Equal(t, "e44c2a9", decodeEntry(t, buf)["@request_id"])
+}
@@ -0,0 +1,47 @@
+package jlo
+
+import (
+	"github.com/mailru/easyjson/jlexer"
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// MarshalEasyJSON returns the log level as json string
+//
+// Deprecated: log levels are encoded by the Encoder of the logger. Use
+// MarshalText instead, which encoding/json uses as well.
+func (l LogLevel) MarshalEasyJSON() ([]byte, error) {
+	return appendJSONString(nil, l.String()), nil
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+//
+// Deprecated: entries are encoded by the Encoder of the logger. The method
+// is kept for compatibility and will be removed in the next major version.
+func (e Entry) MarshalEasyJSON(w *jwriter.Writer) {
+	if e == nil && w.Flags&jwriter.NilMapAsEmpty == 0 {
+		w.RawString("null")
+		return
+	}
+
+	b, err := appendJSONObject(nil, e)
+	w.Raw(b, err)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+//
+// Deprecated: the method is kept for compatibility and will be removed in the
+// next major version.
+func (e *Entry) UnmarshalEasyJSON(in *jlexer.Lexer) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*e = nil
+	} else if m, ok := in.Interface().(map[string]interface{}); ok {
+		*e = Entry(m)
+	} else {
+		in.AddError(&jlexer.LexerError{Reason: "expected json object"})
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
@@ -0,0 +1,35 @@
+package jlo_test
+
+import (
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+	"github.com/mailru/easyjson"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Entry_MarshalEasyJSON(t *testing.T) {
+	b, err := easyjson.Marshal(jlo.Entry{"b": 1, "a": "I'm real", "c": jlo.Entry{"d": true}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"I'm real","b":1,"c":{"d":true}}`, string(b))
+
+	b, err = easyjson.Marshal(jlo.Entry(nil))
+	require.NoError(t, err)
+	assert.Equal(t, `null`, string(b))
+}
+
+func Test_Entry_UnmarshalEasyJSON(t *testing.T) {
+	var e jlo.Entry
+	require.NoError(t, easyjson.Unmarshal([]byte(`{"a":"I'm real","b":1}`), &e))
+	assert.Equal(t, jlo.Entry{"a": "I'm real", "b": 1.0}, e)
+
+	assert.Error(t, easyjson.Unmarshal([]byte(`["I'm real"]`), &e))
+}
+
+func Test_LogLevel_MarshalEasyJSON(t *testing.T) {
+	b, err := jlo.InfoLevel.MarshalEasyJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `"info"`, string(b))
+}
@@ -0,0 +1,402 @@
+package jlo
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// field is a custom log field along with its pre-encoded json representation
+type field struct {
+	key   string
+	value interface{}
+	// json holds the encoded `"key":value` pair
+	json []byte
+	// err holds the error which occurred while marshaling the value
+	err error
+	// deleted marks entry fields removed by hooks, hiding the logger field of
+	// the same name
+	deleted bool
+}
+
+// newField creates a field and encodes its json representation upfront, so it
+// doesn't need to be encoded again on every log call
+func newField(key string, value interface{}) field {
+	b := appendJSONString(nil, key)
+	b = append(b, ':')
+
+	// Values which cannot be marshaled are replaced by a marker instead of
+	// dropping the whole log entry.
+	enc, err := appendJSONValue(b, value)
+	if err != nil {
+		enc = appendJSONString(b, badValue(err))
+	}
+
+	return field{key: key, value: value, json: enc, err: err}
+}
+
+// badValue returns the marker logged in place of values which cannot be
+// marshaled
+func badValue(err error) string {
+	return "!BADVALUE(" + err.Error() + ")"
+}
+
+// fields is a list of log fields sorted by key
+type fields []field
+
+// with returns a copy of the fields with f added or replaced
+func (fs fields) with(f field) fields {
+	i := sort.Search(len(fs), func(i int) bool { return fs[i].key >= f.key })
+	if i < len(fs) && fs[i].key == f.key {
+		out := make(fields, len(fs))
+		copy(out, fs)
+		out[i] = f
+		return out
+	}
+
+	out := make(fields, len(fs)+1)
+	copy(out, fs[:i])
+	out[i] = f
+	copy(out[i+1:], fs[i:])
+	return out
+}
+
+// withEntry returns a copy of the fields with all entry fields added or
+// replaced
+func (fs fields) withEntry(e Entry) fields {
+	add := make(fields, 0, len(e))
+	for k, v := range e {
+		add = append(add, newField(k, v))
+	}
+	sort.Slice(add, func(i, j int) bool { return add[i].key < add[j].key })
+
+	out := make(fields, 0, len(fs)+len(add))
+	for len(fs) > 0 && len(add) > 0 {
+		switch {
+		case fs[0].key < add[0].key:
+			out = append(out, fs[0])
+			fs = fs[1:]
+		case fs[0].key > add[0].key:
+			out = append(out, add[0])
+			add = add[1:]
+		default:
+			out = append(out, add[0])
+			fs, add = fs[1:], add[1:]
+		}
+	}
+	out = append(out, fs...)
+	return append(out, add...)
+}
+
+// kinds of the standard fields every log entry consists of
+const (
+	stdFieldTime = iota
+	stdFieldLevel
+	stdFieldMsg
+)
+
+type stdField struct {
+	key  string
+	kind int
+}
+
+// JSONEncoder encodes log entries as json objects. The keys are written in
+// alphabetical order, with custom fields taking precedence over the standard
+// fields of the same name. It is the default Encoder of a Logger.
+type JSONEncoder struct{}
+
+// AppendEntry implements Encoder
+func (JSONEncoder) AppendEntry(dst []byte, e *EntryView) []byte {
+	l := e.logger
+	std := [...]stdField{
+		{key: l.FieldKeyTime, kind: stdFieldTime},
+		{key: l.FieldKeyLevel, kind: stdFieldLevel},
+		{key: l.FieldKeyMsg, kind: stdFieldMsg},
+	}
+	for i := 1; i < len(std); i++ {
+		for j := i; j > 0 && std[j].key < std[j-1].key; j-- {
+			std[j], std[j-1] = std[j-1], std[j]
+		}
+	}
+
+	dst = append(dst, '{')
+	fs := e.customFields()
+	first := true
+	for _, s := range std {
+		f := fs.peek()
+		for ; f != nil && f.key < s.key; f = fs.peek() {
+			dst = appendJSONSeparator(dst, &first)
+			dst = append(dst, f.json...)
+			fs.next()
+		}
+		if f != nil && f.key == s.key {
+			continue
+		}
+
+		dst = appendJSONSeparator(dst, &first)
+		dst = appendJSONString(dst, s.key)
+		dst = append(dst, ':')
+
+		switch s.kind {
+		case stdFieldTime:
+			dst = l.appendTime(dst, e.time)
+		case stdFieldLevel:
+			dst = appendJSONString(dst, e.level.String())
+		case stdFieldMsg:
+			dst = appendJSONBytes(dst, e.msg.b)
+		}
+	}
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		dst = appendJSONSeparator(dst, &first)
+		dst = append(dst, f.json...)
+		fs.next()
+	}
+
+	return append(dst, '}')
+}
+
+func appendJSONSeparator(dst []byte, first *bool) []byte {
+	if *first {
+		*first = false
+		return dst
+	}
+	return append(dst, ',')
+}
+
+// appendJSONValue appends the json representation of v to dst. Common types are
+// encoded directly, everything else falls back to encoding/json.
+func appendJSONValue(dst []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case string:
+		return appendJSONString(dst, v), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(dst, v, 10), nil
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(dst, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(dst, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(dst, v, 10), nil
+	case float32:
+		if f := float64(v); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return appendJSONFloat(dst, f, 32), nil
+		}
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return appendJSONFloat(dst, v, 64), nil
+		}
+	case time.Time:
+		return appendJSONTime(dst, v), nil
+	case error:
+		msg, ok := errorMessage(v)
+		if !ok {
+			return append(dst, "null"...), nil
+		}
+		return appendJSONString(dst, msg), nil
+	case Entry:
+		return appendJSONObject(dst, v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, b...), nil
+}
+
+// isNilPointer reports whether v holds a nil pointer, e.g. a typed nil error
+// whose Error method would panic
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// errorMessage returns the message of err. It reports false for typed nil
+// errors and errors whose Error method panics, e.g. as they wrap a typed nil
+// error.
+func errorMessage(err error) (msg string, ok bool) {
+	if isNilPointer(err) {
+		return "", false
+	}
+
+	defer func() {
+		if recover() != nil {
+			msg, ok = "", false
+		}
+	}()
+	return err.Error(), true
+}
+
+// appendJSONObject appends the entry as json object with its keys sorted
+func appendJSONObject(dst []byte, e Entry) ([]byte, error) {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dst = append(dst, '{')
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+
+		var err error
+		if dst, err = appendJSONValue(dst, e[k]); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// appendJSONFloat formats floats the same way encoding/json does
+func appendJSONFloat(dst []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+func appendJSONTime(dst []byte, t time.Time) []byte {
+	dst = append(dst, '"')
+	dst = t.AppendFormat(dst, time.RFC3339Nano)
+	return append(dst, '"')
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s as quoted json string to dst, escaping it the same
+// way encoding/json does
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if isSafeJSONByte(b) {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			dst = appendJSONEscapedByte(dst, b)
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendJSONBytes is the same as appendJSONString, but for byte slices
+func appendJSONBytes(dst []byte, s []byte) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if isSafeJSONByte(b) {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			dst = appendJSONEscapedByte(dst, b)
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// isSafeJSONByte reports whether the ASCII character b can be written to a json
+// string without escaping
+func isSafeJSONByte(b byte) bool {
+	return b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&'
+}
+
+func appendJSONEscapedByte(dst []byte, b byte) []byte {
+	switch b {
+	case '"', '\\':
+		return append(dst, '\\', b)
+	case '\b':
+		return append(dst, '\\', 'b')
+	case '\f':
+		return append(dst, '\\', 'f')
+	case '\n':
+		return append(dst, '\\', 'n')
+	case '\r':
+		return append(dst, '\\', 'r')
+	case '\t':
+		return append(dst, '\\', 't')
+	default:
+		return append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+	}
+}
@@ -0,0 +1,203 @@
+package jlo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMarshaler struct {
+	Name string
+}
+
+func (m testMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "custom": "` + m.Name + `" }`), nil
+}
+
+func Test_Logger_WithField_EncodesLikeEncodingJSON(t *testing.T) {
+
+	tests := map[string]interface{}{
+		"nil":                 nil,
+		"string":              "I'm real",
+		"string with escapes": "\"quoted\" \\ \b \f \n \r \t \x01 \x1f",
+		"string with html":    "<script>alert('I'm real')</script> & more",
+		"string with unicode": "Gr\u00fc\u00dfe \U0001f918 \u2028 \u2029",
+		"invalid utf8":        "I'm \xff real",
+		"bool":                true,
+		"int":                 -42,
+		"int8":                int8(-8),
+		"int16":               int16(-16),
+		"int32":               int32(-32),
+		"int64":               int64(math.MinInt64),
+		"uint":                uint(42),
+		"uint8":               uint8(8),
+		"uint16":              uint16(16),
+		"uint32":              uint32(32),
+		"uint64":              uint64(math.MaxUint64),
+		"float32":             float32(2.1),
+		"float64":             2.1,
+		"float64 zero":        0.0,
+		"float64 tiny":        0.000000123,
+		"float64 huge":        1.5e21,
+		"float64 negative":    -1e-9,
+		"time":                time.Date(2018, 8, 2, 21, 48, 56, 856339554, time.UTC),
+		"time with zone":      time.Date(2018, 8, 2, 21, 48, 56, 0, time.FixedZone("CEST", 2*60*60)),
+		"json marshaler":      testMarshaler{Name: "real"},
+		"slice":               []string{"I'm", "real"},
+		"map":                 map[string]int{"b": 2, "a": 1},
+		"struct":              struct{ Real bool }{Real: true},
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.WithField("value", value).Infof("I'm real")
+
+			expected, err := json.Marshal(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, fmt.Sprintf(
+				`{"@level":"info","@message":"I'm real","@timestamp":"%s","value":%s}`+"\n",
+				testTime, expected,
+			), buf.String())
+		})
+	}
+}
+
+func Test_Logger_WithField_Error(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.WithField("error", errors.New("I'm real")).Infof("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@level": "info",
+		"@message": "I'm real",
+		"@timestamp": "%s",
+		"error": "I'm real"
+	}`, testTime), buf.String())
+}
+
+// nilError panics when its Error method is called on a nil pointer
+type nilError struct {
+	msg string
+}
+
+func (e *nilError) Error() string {
+	return e.msg
+}
+
+func Test_Logger_WithField_TypedNilError(t *testing.T) {
+	var err *nilError
+
+	tests := map[string]struct {
+		Encoder  jlo.Encoder
+		Log      func(l *jlo.Logger)
+		Expected string
+	}{
+		"json": {
+			Log:      func(l *jlo.Logger) { l.WithField("error", err).Infof("I'm real") },
+			Expected: fmt.Sprintf(`{"@level":"info","@message":"I'm real","@timestamp":"%s","error":null}`, testTime),
+		},
+		"json nested": {
+			Log:      func(l *jlo.Logger) { l.WithField("details", jlo.Entry{"error": err}).Infof("I'm real") },
+			Expected: fmt.Sprintf(`{"@level":"info","@message":"I'm real","@timestamp":"%s","details":{"error":null}}`, testTime),
+		},
+		"logfmt": {
+			Encoder:  jlo.LogfmtEncoder{},
+			Log:      func(l *jlo.Logger) { l.WithField("error", err).Infof("I'm real") },
+			Expected: fmt.Sprintf(`@timestamp=%s @level=info @message="I'm real" error=null`, testTime),
+		},
+		"with error": {
+			Log:      func(l *jlo.Logger) { l.WithError(err).Infof("I'm real") },
+			Expected: fmt.Sprintf(`{"@level":"info","@message":"I'm real","@timestamp":"%s"}`, testTime),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf, jlo.WithEncoder(test.Encoder))
+
+			test.Log(l)
+			assert.Equal(t, test.Expected+"\n", buf.String())
+		})
+	}
+}
+
+func Test_Logger_WithError_WrappedTypedNilError(t *testing.T) {
+	var err *nilError
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetStacktraceLevel(jlo.ErrorLevel)
+
+	assert.NotPanics(t, func() {
+		l.WithError(errors.Join(errors.New("I'm real"), err)).Errorf("I'm real")
+	})
+	assert.Contains(t, buf.String(), `"@error_chain":["I'm real"]`)
+}
+
+func Test_Logger_WithField_UnsupportedValue(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	var errs []string
+	l.SetErrorHandler(func(err error) { errs = append(errs, err.Error()) })
+	l.WithField("value", math.NaN()).
+		WithField("channel", make(chan int)).
+		Infof("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@level": "info",
+		"@message": "I'm real",
+		"@timestamp": "%s",
+		"channel": "!BADVALUE(json: unsupported type: chan int)",
+		"value": "!BADVALUE(json: unsupported value: NaN)"
+	}`, testTime), buf.String())
+	assert.Equal(t, []string{
+		`marshal field "value": json: unsupported value: NaN`,
+		`marshal field "channel": json: unsupported type: chan int`,
+	}, errs)
+	assert.Equal(t, jlo.Stats{MarshalErrors: 2}, l.Stats())
+}
+
+func Test_Logger_WithField_KeyOrder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.WithField("z", 1).
+		WithField("@a", 2).
+		WithField("@m", 3).
+		WithField("@message", "overwritten").
+		WithField("a", 4).
+		Infof("I'm real")
+
+	assert.Equal(t, fmt.Sprintf(
+		`{"@a":2,"@level":"info","@m":3,"@message":"overwritten","@timestamp":"%s","a":4,"z":1}`+"\n",
+		testTime,
+	), buf.String())
+}
+
+func Test_Logger_WithField_OverwritesParentField(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.WithField("@request_id", "parent").
+		WithField("@request_id", "child").
+		Infof("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@level": "info",
+		"@message": "I'm real",
+		"@timestamp": "%s",
+		"@request_id": "child"
+	}`, testTime), buf.String())
+}
@@ -0,0 +1,24 @@
+package jlo
+
+// Encoder serializes log entries
+type Encoder interface {
+	// AppendEntry appends the encoded entry to dst without a trailing newline
+	AppendEntry(dst []byte, e *EntryView) []byte
+}
+
+// SetEncoder changes the encoder used to serialize log entries. A nil encoder
+// restores the default, which is JSONEncoder.
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.encoder = enc
+}
+
+// generateLogEntry appends the log entry encoded by the logger encoder to dst
+func (l *Logger) generateLogEntry(dst []byte, e *EntryView) []byte {
+	if l.encoder == nil {
+		return JSONEncoder{}.AppendEntry(dst, e)
+	}
+	return l.encoder.AppendEntry(dst, e)
+}
@@ -0,0 +1,40 @@
+package jlo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeEncoder is a custom encoder built on the exported EntryView methods
+type pipeEncoder struct{}
+
+func (pipeEncoder) AppendEntry(dst []byte, e *jlo.EntryView) []byte {
+	dst = e.AppendTime(dst)
+	dst = append(dst, fmt.Sprintf("|%s=%s|%s=%s", e.FieldKeyLevel(), e.Level(), e.FieldKeyMsg(), e.Message())...)
+	e.Range(func(key string, value interface{}) bool {
+		dst = append(dst, fmt.Sprintf("|%s=%v", key, value)...)
+		return true
+	})
+	if v, ok := e.Field("@request_id"); ok {
+		dst = append(dst, fmt.Sprintf("|request=%v", v)...)
+	}
+	return dst
+}
+
+func Test_Logger_SetEncoder_Custom(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetEncoder(pipeEncoder{})
+	l.SetReportFunction(true)
+
+	l.WithField("@request_id", "e44c2a9").WithField("@version", 2.1).Errorf("I'm %s", "real")
+
+	assert.Equal(t, `"`+testTime+`"|@level=error|@message=I'm real`+
+		`|@function=github.com/dcmn-com/jlo_test.Test_Logger_SetEncoder_Custom`+
+		`|@request_id=e44c2a9|@version=2.1|request=e44c2a9`+"\n", buf.String())
+}
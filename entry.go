@@ -0,0 +1,213 @@
+package jlo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EntryView gives access to a single log entry while it is being encoded. It
+// is only valid until the Encoder returns and must not be retained. Hooks may
+// modify the entry before it is encoded.
+type EntryView struct {
+	logger *Logger
+	ctx    context.Context
+	time   time.Time
+	level  LogLevel
+	msg    buffer
+	// fields holds fields which are only part of this entry, sorted by key.
+	// They take precedence over the logger fields of the same name.
+	fields fields
+}
+
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return &EntryView{msg: buffer{b: make([]byte, 0, 256)}}
+	},
+}
+
+// getEntry returns an empty entry of the logger from the pool
+func getEntry(l *Logger) *EntryView {
+	e := entryPool.Get().(*EntryView)
+	e.logger = l
+	e.msg.b = e.msg.b[:0]
+	e.fields = e.fields[:0]
+	return e
+}
+
+// putEntry returns the entry to the pool
+func putEntry(e *EntryView) {
+	if cap(e.msg.b) > maxPooledBufferSize {
+		return
+	}
+	e.logger = nil
+	e.ctx = nil
+	for i := range e.fields {
+		e.fields[i] = field{}
+	}
+	entryPool.Put(e)
+}
+
+// Context returns the context the entry is logged with, which is
+// context.Background() for entries logged without a context
+func (e *EntryView) Context() context.Context {
+	return e.ctx
+}
+
+// Time returns the timestamp of the entry
+func (e *EntryView) Time() time.Time {
+	return e.time
+}
+
+// AppendTime appends the timestamp of the entry to dst as encoded by the
+// TimeEncoder of the logger
+func (e *EntryView) AppendTime(dst []byte) []byte {
+	return e.logger.appendTime(dst, e.time)
+}
+
+// Level returns the log level of the entry
+func (e *EntryView) Level() LogLevel {
+	return e.level
+}
+
+// Message returns the formatted log message of the entry
+func (e *EntryView) Message() string {
+	return string(e.msg.b)
+}
+
+// FieldKeyTime returns the name of the timestamp field
+func (e *EntryView) FieldKeyTime() string {
+	return e.logger.FieldKeyTime
+}
+
+// FieldKeyLevel returns the name of the log level field
+func (e *EntryView) FieldKeyLevel() string {
+	return e.logger.FieldKeyLevel
+}
+
+// FieldKeyMsg returns the name of the log message field
+func (e *EntryView) FieldKeyMsg() string {
+	return e.logger.FieldKeyMsg
+}
+
+// Field returns the value of the custom field with the given key
+func (e *EntryView) Field(key string) (interface{}, bool) {
+	if f := e.fields.find(key); f != nil {
+		return f.value, !f.deleted
+	}
+	if f := e.logger.fields.find(key); f != nil {
+		return f.value, true
+	}
+	return nil, false
+}
+
+// Range calls fn for all custom fields of the entry in key order until fn
+// returns false
+func (e *EntryView) Range(fn func(key string, value interface{}) bool) {
+	fs := e.customFields()
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		if !fn(f.key, f.value) {
+			return
+		}
+		fs.next()
+	}
+}
+
+// SetMessage replaces the log message of the entry
+func (e *EntryView) SetMessage(msg string) {
+	e.msg.b = append(e.msg.b[:0], msg...)
+}
+
+// SetField sets a custom field of the entry, replacing a logger field of the
+// same name
+func (e *EntryView) SetField(key string, value interface{}) {
+	f := newField(key, value)
+	e.setField(f)
+	e.logger.reportMarshalError(&f)
+}
+
+// DeleteField removes a custom field from the entry, including a logger field
+// of the same name
+func (e *EntryView) DeleteField(key string) {
+	e.setField(field{key: key, deleted: true})
+}
+
+// customFields returns an iterator over the logger and entry fields
+func (e *EntryView) customFields() mergedFields {
+	return mergedFields{logger: e.logger.fields, entry: e.fields}
+}
+
+// addField adds a field to the entry, replacing an existing field of the same
+// name
+func (e *EntryView) addField(key string, value interface{}) {
+	e.setField(newField(key, value))
+}
+
+// setField adds the field to the entry, keeping the fields sorted by key
+func (e *EntryView) setField(f field) {
+	i := len(e.fields)
+	for i > 0 && e.fields[i-1].key >= f.key {
+		i--
+	}
+	if i < len(e.fields) && e.fields[i].key == f.key {
+		e.fields[i] = f
+		return
+	}
+
+	e.fields = append(e.fields, field{})
+	copy(e.fields[i+1:], e.fields[i:])
+	e.fields[i] = f
+}
+
+// find returns the field with the given key or nil
+func (fs fields) find(key string) *field {
+	i := sort.Search(len(fs), func(i int) bool { return fs[i].key >= key })
+	if i < len(fs) && fs[i].key == key {
+		return &fs[i]
+	}
+	return nil
+}
+
+// mergedFields iterates over the logger fields and the entry fields in key
+// order, skipping logger fields overwritten by the entry
+type mergedFields struct {
+	logger fields
+	entry  fields
+}
+
+// peek returns the next field without consuming it. Deleted entry fields are
+// skipped along with the logger fields they hide.
+func (m *mergedFields) peek() *field {
+	for {
+		for len(m.logger) > 0 && len(m.entry) > 0 && m.logger[0].key == m.entry[0].key {
+			m.logger = m.logger[1:]
+		}
+
+		switch {
+		case len(m.logger) == 0 && len(m.entry) == 0:
+			return nil
+		case len(m.entry) == 0:
+			return &m.logger[0]
+		case len(m.logger) == 0 || m.entry[0].key < m.logger[0].key:
+			if m.entry[0].deleted {
+				m.entry = m.entry[1:]
+				continue
+			}
+			return &m.entry[0]
+		default:
+			return &m.logger[0]
+		}
+	}
+}
+
+// next consumes the field returned by peek
+func (m *mergedFields) next() {
+	if f := m.peek(); f != nil {
+		if len(m.entry) > 0 && f == &m.entry[0] {
+			m.entry = m.entry[1:]
+		} else {
+			m.logger = m.logger[1:]
+		}
+	}
+}
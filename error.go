@@ -0,0 +1,87 @@
+package jlo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// LogFielder is implemented by errors which contribute their own fields to log
+// entries created with WithError
+type LogFielder interface {
+	LogFields() Entry
+}
+
+// WithError returns a copy of the logger with fields describing err set, which
+// will be included in all subsequent logs:
+//
+//   - @error holds the error message
+//   - @error_type holds the type of the error
+//   - @error_chain holds the messages of all errors wrapped by err, if any
+//   - @error_details holds the json representation of the first error in the
+//     chain implementing json.Marshaler, if any
+//
+// The fields returned by the first error in the chain implementing LogFielder
+// are set as well.
+func (l *Logger) WithError(err error) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	clone := l.clone()
+	// typed nil errors are treated like nil, as their methods may panic
+	if err == nil || isNilPointer(err) {
+		return clone
+	}
+
+	fields := make(Entry)
+
+	var fielder LogFielder
+	if errors.As(err, &fielder) {
+		for k, v := range fielder.LogFields() {
+			fields[k] = v
+		}
+	}
+
+	fields[FieldKeyError] = err
+	fields[FieldKeyErrorType] = fmt.Sprintf("%T", err)
+
+	if chain := errorChain(err, nil); len(chain) > 0 {
+		fields[FieldKeyErrorChain] = chain
+	}
+
+	var marshaler json.Marshaler
+	if errors.As(err, &marshaler) {
+		if details, merr := marshaler.MarshalJSON(); merr == nil {
+			fields[FieldKeyErrorDetails] = json.RawMessage(details)
+		}
+	}
+
+	clone.fields = l.fields.withEntry(fields)
+	l.reportMarshalErrors(clone.fields, fields)
+	return clone
+}
+
+// errorChain appends the messages of all errors wrapped by err to chain,
+// following both single and multi error wrapping depth-first
+func errorChain(err error, chain []string) []string {
+	switch err := err.(type) {
+	case interface{ Unwrap() error }:
+		if wrapped := err.Unwrap(); wrapped != nil {
+			if msg, ok := errorMessage(wrapped); ok {
+				chain = append(chain, msg)
+				chain = errorChain(wrapped, chain)
+			}
+		}
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range err.Unwrap() {
+			if wrapped == nil {
+				continue
+			}
+			if msg, ok := errorMessage(wrapped); ok {
+				chain = append(chain, msg)
+				chain = errorChain(wrapped, chain)
+			}
+		}
+	}
+	return chain
+}
@@ -0,0 +1,125 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldsError struct {
+	code int
+}
+
+func (e *fieldsError) Error() string {
+	return fmt.Sprintf("failed with code %d", e.code)
+}
+
+func (e *fieldsError) LogFields() jlo.Entry {
+	return jlo.Entry{"@error_code": e.code}
+}
+
+type detailsError struct {
+	Reason string `json:"reason"`
+}
+
+func (e detailsError) Error() string {
+	return "failed: " + e.Reason
+}
+
+func (e detailsError) MarshalJSON() ([]byte, error) {
+	return []byte(`{"reason": "` + e.Reason + `"}`), nil
+}
+
+func Test_Logger_WithError(t *testing.T) {
+
+	tests := map[string]struct {
+		Err    error
+		Fields string
+	}{
+		"simple": {
+			Err: errors.New("I'm real"),
+			Fields: `
+				"@error": "I'm real",
+				"@error_type": "*errors.errorString"
+			`,
+		},
+		"wrapped": {
+			Err: fmt.Errorf("outer: %w", fmt.Errorf("middle: %w", errors.New("inner"))),
+			Fields: `
+				"@error": "outer: middle: inner",
+				"@error_type": "*fmt.wrapError",
+				"@error_chain": ["middle: inner", "inner"]
+			`,
+		},
+		"joined": {
+			Err: errors.Join(errors.New("first"), fmt.Errorf("second: %w", errors.New("inner"))),
+			Fields: `
+				"@error": "first\nsecond: inner",
+				"@error_type": "*errors.joinError",
+				"@error_chain": ["first", "second: inner", "inner"]
+			`,
+		},
+		"log fielder": {
+			Err: fmt.Errorf("wrapped: %w", &fieldsError{code: 42}),
+			Fields: `
+				"@error": "wrapped: failed with code 42",
+				"@error_type": "*fmt.wrapError",
+				"@error_chain": ["failed with code 42"],
+				"@error_code": 42
+			`,
+		},
+		"json marshaler": {
+			Err: detailsError{Reason: "I'm real"},
+			Fields: `
+				"@error": "failed: I'm real",
+				"@error_type": "jlo_test.detailsError",
+				"@error_details": {"reason": "I'm real"}
+			`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+
+			l.WithError(test.Err).Errorf("I'm real")
+
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"@message":   "I'm real",
+				"@level":     "error",
+				"@timestamp": "%s",
+				%s
+			}`, testTime, test.Fields), buf.String())
+		})
+	}
+}
+
+func Test_Logger_WithError_Nil(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+
+	l.WithError(nil).Errorf("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":   "I'm real",
+		"@level":     "error",
+		"@timestamp": "%s"
+	}`, testTime), buf.String())
+}
+
+func Test_Logger_WithError_StackTrace(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetStacktraceLevel(jlo.ErrorLevel)
+
+	l.WithError(fmt.Errorf("wrapped: %w", newStackError("I'm real"))).Errorf("I'm real")
+
+	funcs := stacktraceFunctions(t, decodeEntry(t, buf))
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.newStackError", funcs[0])
+}
@@ -1,7 +1,10 @@
 package jlo_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/dcmn-com/jlo"
@@ -13,6 +16,15 @@ func ExampleLogLevel_String() {
 	// Output: debug
 }
 
+func ExampleParseLogLevel() {
+	level, err := jlo.ParseLogLevel("WARN")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(level)
+	// Output: warning
+}
+
 func ExampleNewLogger() {
 	l := jlo.NewLogger(os.Stdout)
 	l.FieldKeyLevel = "lvl"
@@ -61,9 +73,14 @@ func ExampleLogger_Errorf() {
 
 func ExampleLogger_Fatalf() {
 	l := jlo.NewLogger(os.Stdout)
+	l.SetExitFunc(func(code int) {
+		fmt.Println("exit", code)
+	})
 
 	l.Fatalf("I'm real")
-	// Output: {"@level":"fatal","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}
+	// Output:
+	// {"@level":"fatal","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}
+	// exit 1
 }
 
 func ExampleLogger_SetLogLevel() {
@@ -82,9 +99,60 @@ func ExampleLogger_WithField() {
 	// Output: {"@level":"info","@message":"I'm real","@request_id":"aa33ee55","@timestamp":"2018-08-02T21:48:56.856339554Z"}
 }
 
-func ExampleLogger_WithField_Chaining() {
+func ExampleLogger_WithField_chaining() {
 	l := jlo.NewLogger(os.Stdout)
 
 	l.WithField("@request_id", "aa33ee55").Infof("I'm real")
 	// Output: {"@level":"info","@message":"I'm real","@request_id":"aa33ee55","@timestamp":"2018-08-02T21:48:56.856339554Z"}
 }
+
+func ExampleLogger_WithFields() {
+	l := jlo.NewLogger(os.Stdout)
+
+	l.WithFields(jlo.Entry{"@request_id": "aa33ee55", "@user_id": 42}).Infof("I'm real")
+	// Output: {"@level":"info","@message":"I'm real","@request_id":"aa33ee55","@timestamp":"2018-08-02T21:48:56.856339554Z","@user_id":42}
+}
+
+func ExampleNewSlogHandler() {
+	l := jlo.NewLogger(os.Stdout)
+
+	slog.New(jlo.NewSlogHandler(l)).Info("I'm real", "@request_id", "aa33ee55")
+	// Output: {"@level":"info","@message":"I'm real","@request_id":"aa33ee55","@timestamp":"2018-08-02T21:48:56.856339554Z"}
+}
+
+func ExampleLogger_WithError() {
+	l := jlo.NewLogger(os.Stdout)
+
+	err := fmt.Errorf("loading config: %w", errors.New("file not found"))
+	l.WithError(err).Errorf("I'm real")
+	// Output: {"@error":"loading config: file not found","@error_chain":["file not found"],"@error_type":"*fmt.wrapError","@level":"error","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}
+}
+
+func ExampleLogfmtEncoder() {
+	l := jlo.NewLogger(os.Stdout)
+	l.SetEncoder(jlo.LogfmtEncoder{})
+
+	l.WithField("@request_id", "aa33ee55").Infof("I'm real")
+	// Output: @timestamp=2018-08-02T21:48:56.856339554Z @level=info @message="I'm real" @request_id=aa33ee55
+}
+
+func ExampleWithConsoleEncoder() {
+	l := jlo.NewLogger(os.Stdout, jlo.WithConsoleEncoder())
+
+	l.WithField("@request_id", "aa33ee55").Infof("I'm real")
+	// Output: 21:48:56.856 INF I'm real @request_id=aa33ee55
+}
+
+func ExampleFromContext() {
+	type requestIDKey struct{}
+
+	l := jlo.NewLogger(os.Stdout, jlo.WithContextExtractors(
+		jlo.ContextValueExtractor("@request_id", requestIDKey{}),
+	))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "aa33ee55")
+	ctx = jlo.NewContext(ctx, l)
+
+	jlo.FromContext(ctx).InfoContext(ctx, "I'm real")
+	// Output: {"@level":"info","@message":"I'm real","@request_id":"aa33ee55","@timestamp":"2018-08-02T21:48:56.856339554Z"}
+}
@@ -0,0 +1,412 @@
+package jlo
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in names of rotated files
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileConfig configures the rotation of a FileWriter
+type FileConfig struct {
+	// MaxSize is the size in bytes after which the file is rotated. Zero
+	// disables size based rotation.
+	MaxSize int64
+	// Interval is the interval in which the file is rotated, aligned to
+	// multiples of the interval since the zero time, so a 24h interval rotates
+	// at midnight UTC. Zero disables time based rotation.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep. Zero keeps all.
+	MaxBackups int
+	// Compress enables gzip compression of rotated files in the background
+	Compress bool
+	// Clock provides the time for rotation and names of rotated files. It
+	// defaults to the package-level Now function.
+	Clock Clock
+}
+
+// FileWriter writes to a file which is rotated by size and/or time. Rotated
+// files are renamed to the file name suffixed with the rotation time, e.g.
+// "app-2018-08-02T21-48-56.856.log".
+type FileWriter struct {
+	path   string
+	config FileConfig
+
+	mu     sync.Mutex
+	closed bool
+
+	// file is nil if opening it failed, it is opened again on the next write
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	// background serializes compression and removal of rotated files
+	background sync.Mutex
+	wg         sync.WaitGroup
+}
+
+// NewFileWriter opens the file at path for appending, creating it and its
+// directory if necessary
+func NewFileWriter(path string, config FileConfig) (*FileWriter, error) {
+	w := &FileWriter{path: path, config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// now returns the current time of the configured clock
+func (w *FileWriter) now() time.Time {
+	if w.config.Clock != nil {
+		return w.config.Clock.Now()
+	}
+	return Now()
+}
+
+// open opens the file and resets the rotation state. The caller must hold w.mu.
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	if w.config.Interval > 0 {
+		w.nextRotation = w.now().Truncate(w.config.Interval).Add(w.config.Interval)
+	}
+	return nil
+}
+
+// Write writes p to the file, rotating it beforehand if it exceeds the maximum
+// size or the rotation interval has passed
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.rotationDue(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotationDue reports whether the file must be rotated before writing n bytes.
+// The caller must hold w.mu.
+func (w *FileWriter) rotationDue(n int) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.config.MaxSize {
+		return true
+	}
+	return w.config.Interval > 0 && !w.now().Before(w.nextRotation)
+}
+
+// Rotate rotates the file immediately
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	return w.rotate()
+}
+
+// rotate renames the current file to a timestamped backup, opens a new file
+// and starts compression and removal of old backups in the background. If
+// closing or opening the file fails, it is opened again on the next write. The
+// caller must hold w.mu.
+func (w *FileWriter) rotate() error {
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	backup := w.backupName(w.now())
+	if err := os.Rename(w.path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		// keep writing to the current file
+		if oerr := w.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		w.background.Lock()
+		defer w.background.Unlock()
+
+		if w.config.Compress {
+			compressFile(backup)
+		}
+		w.removeBackups()
+	}()
+	return nil
+}
+
+// backupName returns an unused name for a file rotated at t. Files rotated
+// within the same millisecond are numbered after the highest existing index,
+// as reusing the index of a removed backup would break the order of backups.
+func (w *FileWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	stamp := t.UTC().Format(backupTimeFormat)
+	prefix := strings.TrimSuffix(w.path, ext) + "-" + stamp
+
+	index := -1
+	files, _ := w.backupFiles()
+	for _, b := range files {
+		if b.time.Format(backupTimeFormat) == stamp && b.index > index {
+			index = b.index
+		}
+	}
+
+	if index < 0 {
+		return prefix + ext
+	}
+	return prefix + "." + strconv.Itoa(index+1) + ext
+}
+
+// backupFile is a rotated file with the time and index parsed from its name
+type backupFile struct {
+	name  string
+	time  time.Time
+	index int
+}
+
+// backups returns the names of all rotated files from oldest to newest
+func (w *FileWriter) backups() ([]string, error) {
+	files, err := w.backupFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(files))
+	for i, b := range files {
+		names[i] = b.name
+	}
+	return names, nil
+}
+
+// backupFiles returns all rotated files from oldest to newest
+func (w *FileWriter) backupFiles() ([]backupFile, error) {
+	dir := filepath.Dir(w.path)
+	ext := filepath.Ext(w.path)
+	prefix := filepath.Base(strings.TrimSuffix(w.path, ext)) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		// names are <prefix><time>[.<index>]<ext>[.gz]
+		rest := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		if len(rest) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, rest[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+
+		var index int
+		if rest = rest[len(backupTimeFormat):]; rest != "" {
+			if index, err = strconv.Atoi(strings.TrimPrefix(rest, ".")); err != nil || rest[0] != '.' {
+				continue
+			}
+		}
+
+		backups = append(backups, backupFile{name: filepath.Join(dir, name), time: t, index: index})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.Before(backups[j].time)
+		}
+		return backups[i].index < backups[j].index
+	})
+	return backups, nil
+}
+
+// removeBackups removes the oldest rotated files exceeding MaxBackups
+func (w *FileWriter) removeBackups() {
+	if w.config.MaxBackups <= 0 {
+		return
+	}
+
+	names, err := w.backups()
+	if err != nil {
+		return
+	}
+	for len(names) > w.config.MaxBackups {
+		os.Remove(names[0])
+		names = names[1:]
+	}
+}
+
+// Reopen closes and reopens the file, so that the file is recreated after it
+// was moved by an external tool like logrotate. It also recovers from previous
+// failures to open the file.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	var cerr error
+	if w.file != nil {
+		cerr = w.file.Close()
+		w.file = nil
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	return cerr
+}
+
+// ReopenOnSignal reopens the file whenever one of the given signals is
+// received, SIGHUP if none are given. Without signals it does nothing on
+// platforms lacking SIGHUP. The returned function stops listening.
+func (w *FileWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = defaultReopenSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Sync commits the contents of the file to stable storage
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close closes the file and waits until rotated files are compressed.
+// Subsequent writes fail with ErrWriterClosed.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	w.closed = true
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+// compressFile replaces the file at name with a gzip compressed copy
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
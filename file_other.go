@@ -0,0 +1,9 @@
+//go:build !unix
+
+package jlo
+
+import "os"
+
+// defaultReopenSignals are the signals ReopenOnSignal listens to if none are
+// given. There is no equivalent of SIGHUP on this platform.
+var defaultReopenSignals []os.Signal
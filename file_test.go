@@ -0,0 +1,243 @@
+package jlo_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirFiles returns the sorted names of all files in dir
+func dirFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, name string) string {
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func Test_FileWriter_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlo.NewFrozenClock(clockTestTime)
+	w, err := jlo.NewFileWriter(filepath.Join(dir, "app.log"), jlo.FileConfig{MaxSize: 11, Clock: clock})
+	require.NoError(t, err)
+
+	w.Write([]byte("first\n"))
+	w.Write([]byte("real\n"))
+	clock.Add(time.Second)
+	w.Write([]byte("second\n"))
+	clock.Add(time.Second)
+	w.Write([]byte("third and longer than max\n"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2020-02-29T13-37-43.123.log",
+		"app-2020-02-29T13-37-44.123.log",
+		"app.log",
+	}, dirFiles(t, dir))
+	assert.Equal(t, "first\nreal\n", readFile(t, filepath.Join(dir, "app-2020-02-29T13-37-43.123.log")))
+	assert.Equal(t, "second\n", readFile(t, filepath.Join(dir, "app-2020-02-29T13-37-44.123.log")))
+	assert.Equal(t, "third and longer than max\n", readFile(t, filepath.Join(dir, "app.log")))
+}
+
+func Test_FileWriter_Interval(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlo.NewFrozenClock(clockTestTime)
+	w, err := jlo.NewFileWriter(filepath.Join(dir, "app.log"), jlo.FileConfig{Interval: time.Hour, Clock: clock})
+	require.NoError(t, err)
+
+	w.Write([]byte("first\n"))
+	clock.Add(20 * time.Minute)
+	w.Write([]byte("real\n"))
+	clock.Add(3 * time.Minute)
+	w.Write([]byte("second\n"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2020-02-29T14-00-42.123.log", "app.log"}, dirFiles(t, dir))
+	assert.Equal(t, "first\nreal\n", readFile(t, filepath.Join(dir, "app-2020-02-29T14-00-42.123.log")))
+	assert.Equal(t, "second\n", readFile(t, filepath.Join(dir, "app.log")))
+}
+
+func Test_FileWriter_MaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlo.NewFrozenClock(clockTestTime)
+	w, err := jlo.NewFileWriter(filepath.Join(dir, "app.log"), jlo.FileConfig{MaxBackups: 2, Clock: clock})
+	require.NoError(t, err)
+
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		w.Write([]byte(msg + "\n"))
+		require.NoError(t, w.Rotate())
+	}
+	// rotations within the same millisecond get an index
+	w.Write([]byte("5\n"))
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2020-02-29T13-37-42.123.3.log",
+		"app-2020-02-29T13-37-42.123.4.log",
+		"app.log",
+	}, dirFiles(t, dir))
+	assert.Equal(t, "4\n", readFile(t, filepath.Join(dir, "app-2020-02-29T13-37-42.123.3.log")))
+	assert.Equal(t, "5\n", readFile(t, filepath.Join(dir, "app-2020-02-29T13-37-42.123.4.log")))
+}
+
+func Test_FileWriter_BackupIndexAfterRemoval(t *testing.T) {
+	dir := t.TempDir()
+	// the backups without index and with index 1 were removed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-2020-02-29T13-37-42.123.2.log"), []byte("2\n"), 0644))
+
+	w, err := jlo.NewFileWriter(filepath.Join(dir, "app.log"), jlo.FileConfig{Clock: jlo.NewFrozenClock(clockTestTime)})
+	require.NoError(t, err)
+	w.Write([]byte("3\n"))
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2020-02-29T13-37-42.123.2.log",
+		"app-2020-02-29T13-37-42.123.3.log",
+		"app.log",
+	}, dirFiles(t, dir))
+	assert.Equal(t, "3\n", readFile(t, filepath.Join(dir, "app-2020-02-29T13-37-42.123.3.log")))
+}
+
+func Test_FileWriter_Compress(t *testing.T) {
+	dir := t.TempDir()
+	clock := jlo.NewFrozenClock(clockTestTime)
+	w, err := jlo.NewFileWriter(filepath.Join(dir, "app.log"), jlo.FileConfig{Compress: true, MaxBackups: 1, Clock: clock})
+	require.NoError(t, err)
+
+	w.Write([]byte("first\n"))
+	require.NoError(t, w.Rotate())
+	clock.Add(time.Second)
+	w.Write([]byte("second\n"))
+	require.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2020-02-29T13-37-43.123.log.gz", "app.log"}, dirFiles(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "app-2020-02-29T13-37-43.123.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(b))
+}
+
+func Test_FileWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := jlo.NewFileWriter(name, jlo.FileConfig{})
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	require.NoError(t, os.Rename(name, name+".1"))
+	w.Write([]byte("moved\n"))
+
+	require.NoError(t, w.Reopen())
+	w.Write([]byte("second\n"))
+
+	assert.Equal(t, "first\nmoved\n", readFile(t, name+".1"))
+	assert.Equal(t, "second\n", readFile(t, name))
+}
+
+func Test_FileWriter_RecoversFromOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := jlo.NewFileWriter(name, jlo.FileConfig{})
+	require.NoError(t, err)
+	defer w.Close()
+
+	// a directory in place of the file makes opening it fail
+	require.NoError(t, os.Rename(name, name+".1"))
+	require.NoError(t, os.Mkdir(name, 0755))
+
+	err = w.Reopen()
+	require.Error(t, err)
+	assert.NotEqual(t, jlo.ErrWriterClosed, err)
+
+	_, err = w.Write([]byte("lost\n"))
+	require.Error(t, err)
+	assert.NotEqual(t, jlo.ErrWriterClosed, err)
+	assert.NoError(t, w.Sync())
+
+	require.NoError(t, os.Remove(name))
+	_, err = w.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.Rename(name, name+".2"))
+	require.NoError(t, os.Mkdir(name, 0755))
+	require.Error(t, w.Reopen())
+	require.NoError(t, os.Remove(name))
+	require.NoError(t, w.Reopen())
+	_, err = w.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "first\n", readFile(t, name+".2"))
+	assert.Equal(t, "second\n", readFile(t, name))
+}
+
+func Test_FileWriter_Appends(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	w, err := jlo.NewFileWriter(name, jlo.FileConfig{})
+	require.NoError(t, err)
+	w.Write([]byte("first\n"))
+	require.NoError(t, w.Close())
+
+	w, err = jlo.NewFileWriter(name, jlo.FileConfig{MaxSize: 10})
+	require.NoError(t, err)
+	w.Write([]byte("second\n"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "second\n", readFile(t, name))
+	assert.Len(t, dirFiles(t, filepath.Dir(name)), 2)
+}
+
+func Test_FileWriter_Closed(t *testing.T) {
+	w, err := jlo.NewFileWriter(filepath.Join(t.TempDir(), "app.log"), jlo.FileConfig{})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("I'm real\n"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+	assert.Equal(t, jlo.ErrWriterClosed, w.Rotate())
+	assert.NoError(t, w.Close())
+}
+
+func Test_Logger_FileWriter(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	w, err := jlo.NewFileWriter(name, jlo.FileConfig{MaxSize: 1 << 20})
+	require.NoError(t, err)
+
+	l := jlo.NewLogger(w)
+	l.Infof("I'm real")
+	l.Infof("I'm real")
+	require.NoError(t, w.Close())
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, name)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"@message":"I'm real"`)
+}
@@ -0,0 +1,12 @@
+//go:build unix
+
+package jlo
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultReopenSignals are the signals ReopenOnSignal listens to if none are
+// given
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP}
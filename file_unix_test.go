@@ -0,0 +1,39 @@
+//go:build unix
+
+package jlo_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FileWriter_ReopenOnSignal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	w, err := jlo.NewFileWriter(name, jlo.FileConfig{})
+	require.NoError(t, err)
+	defer w.Close()
+
+	stop := w.ReopenOnSignal()
+	defer stop()
+
+	w.Write([]byte("first\n"))
+	require.NoError(t, os.Rename(name, name+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(name)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	w.Write([]byte("second\n"))
+	assert.Equal(t, "first\n", readFile(t, name+".1"))
+	assert.Equal(t, "second\n", readFile(t, name))
+}
@@ -0,0 +1,307 @@
+package jlo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGELFChunkSize is the maximum size of UDP datagrams sent by a
+	// GELFWriter if none is set
+	DefaultGELFChunkSize = 1420
+
+	// gelfChunkHeaderSize is the size of the magic bytes, message id, sequence
+	// number and sequence count of chunks
+	gelfChunkHeaderSize = 12
+	// maxGELFChunks is the maximum number of chunks of a message
+	maxGELFChunks = 128
+)
+
+// GELFEncoder encodes log entries as GELF 1.1 messages for Graylog. The
+// message becomes short_message, the timestamp is written in seconds and the
+// level as syslog severity. Custom fields are prefixed by an underscore with
+// a leading "@" removed, e.g. "@request_id" becomes "_request_id". As GELF only
+// allows strings and numbers, booleans become 0 or 1 and other values their
+// json representation in a string.
+type GELFEncoder struct {
+	// Host defaults to the hostname reported by the kernel
+	Host string
+}
+
+// AppendEntry implements Encoder
+func (enc GELFEncoder) AppendEntry(dst []byte, e *EntryView) []byte {
+	host := enc.Host
+	if host == "" {
+		host = hostname()
+	}
+
+	dst = append(dst, `{"version":"1.1","host":`...)
+	dst = appendJSONString(dst, host)
+	dst = append(dst, `,"short_message":`...)
+	dst = appendJSONBytes(dst, e.msg.b)
+	dst = append(dst, `,"timestamp":`...)
+	dst = appendJSONFloat(dst, float64(e.time.UnixNano())/float64(time.Second), 64)
+	dst = append(dst, `,"level":`...)
+	dst = append(dst, byte('0'+syslogSeverity(e.level)))
+
+	var name [64]byte
+	fs := e.customFields()
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		dst = append(dst, ',')
+		dst = appendJSONBytes(dst, gelfFieldName(name[:0], f.key))
+		dst = append(dst, ':')
+		dst = appendGELFValue(dst, f.value)
+		fs.next()
+	}
+
+	return append(dst, '}')
+}
+
+var (
+	hostnameOnce sync.Once
+	hostnameVal  string
+)
+
+// hostname returns the hostname reported by the kernel, which is looked up
+// only once
+func hostname() string {
+	hostnameOnce.Do(func() {
+		hostnameVal, _ = os.Hostname()
+	})
+	return hostnameVal
+}
+
+// appendGELFValue appends the value of an additional field, which must be a
+// string or a number. Booleans are written as 0 or 1, other values as their
+// json representation in a string.
+func appendGELFValue(dst []byte, v interface{}) []byte {
+	start := len(dst)
+	js, err := appendJSONValue(dst, v)
+	if err != nil {
+		return appendJSONString(dst, badValue(err))
+	}
+
+	switch c := js[start]; {
+	case c == '"', c == '-', c >= '0' && c <= '9':
+		return js
+	case string(js[start:]) == "true":
+		return append(js[:start], '1')
+	case string(js[start:]) == "false":
+		return append(js[:start], '0')
+	}
+
+	raw := string(js[start:])
+	return appendJSONString(js[:start], raw)
+}
+
+// gelfFieldName appends the name of the additional field for key. Characters
+// other than letters, digits, underscores, dashes and dots are replaced by
+// underscores. The reserved field _id is renamed to _id_.
+func gelfFieldName(dst []byte, key string) []byte {
+	if len(key) > 0 && key[0] == '@' {
+		key = key[1:]
+	}
+
+	dst = append(dst, '_')
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' {
+			dst = append(dst, c)
+		} else {
+			dst = append(dst, '_')
+		}
+	}
+
+	if string(dst) == "_id" {
+		dst = append(dst, '_')
+	}
+	return dst
+}
+
+// GELFConfig configures a GELFWriter
+type GELFConfig struct {
+	// Network is "udp" or "tcp"
+	Network string
+	// Address is the address of the Graylog input
+	Address string
+	// Compress enables gzip compression of UDP messages. TCP messages cannot
+	// be compressed.
+	Compress bool
+	// ChunkSize is the maximum size of UDP datagrams. Larger messages are
+	// split into chunks. It defaults to DefaultGELFChunkSize.
+	ChunkSize int
+	// Timeout limits dialing and writing. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// GELFWriter sends log entries encoded by GELFEncoder to Graylog. UDP
+// messages are chunked if necessary, TCP messages are terminated by a null
+// byte. Failed TCP connections are reestablished on the next write.
+type GELFWriter struct {
+	config GELFConfig
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	buf    bytes.Buffer
+	zw     *gzip.Writer
+}
+
+// NewGELFWriter creates a new GELF writer and connects to Graylog
+func NewGELFWriter(config GELFConfig) (*GELFWriter, error) {
+	switch config.Network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errors.New("unsupported network " + config.Network)
+	}
+	if config.ChunkSize <= gelfChunkHeaderSize {
+		config.ChunkSize = DefaultGELFChunkSize
+	}
+
+	w := &GELFWriter{config: config}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// stream reports whether the writer uses TCP
+func (w *GELFWriter) stream() bool {
+	return w.config.Network[:3] == "tcp"
+}
+
+// connect dials Graylog. The caller must hold w.mu.
+func (w *GELFWriter) connect() error {
+	conn, err := net.DialTimeout(w.config.Network, w.config.Address, w.config.Timeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// Write sends the GELF message p. A trailing newline is removed.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	msg := bytes.TrimSuffix(p, []byte{'\n'})
+
+	var err error
+	if w.stream() {
+		err = w.writeTCP(msg)
+	} else {
+		err = w.writeUDP(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeTCP sends the message terminated by a null byte. If sending fails, the
+// connection is reestablished and sending is retried once.
+func (w *GELFWriter) writeTCP(msg []byte) error {
+	w.buf.Reset()
+	w.buf.Write(msg)
+	w.buf.WriteByte(0)
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+
+		if w.config.Timeout > 0 {
+			w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+		}
+		if _, err = w.conn.Write(w.buf.Bytes()); err == nil {
+			return nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// writeUDP sends the optionally compressed message in one datagram or in
+// chunks if it exceeds the chunk size
+func (w *GELFWriter) writeUDP(msg []byte) error {
+	if w.config.Compress {
+		w.buf.Reset()
+		if w.zw == nil {
+			w.zw = gzip.NewWriter(&w.buf)
+		} else {
+			w.zw.Reset(&w.buf)
+		}
+		w.zw.Write(msg)
+		if err := w.zw.Close(); err != nil {
+			return err
+		}
+		msg = w.buf.Bytes()
+	}
+
+	if w.config.Timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+	}
+	if len(msg) <= w.config.ChunkSize {
+		_, err := w.conn.Write(msg)
+		return err
+	}
+
+	size := w.config.ChunkSize - gelfChunkHeaderSize
+	count := (len(msg) + size - 1) / size
+	if count > maxGELFChunks {
+		return errors.New("gelf message too large")
+	}
+
+	chunk := make([]byte, 0, w.config.ChunkSize)
+	chunk = append(chunk, 0x1e, 0x0f)
+	chunk = append(chunk, make([]byte, 8)...)
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk = append(chunk, 0, byte(count))
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk[10] = byte(i)
+		chunk = append(chunk[:gelfChunkHeaderSize], msg[i*size:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to Graylog. Subsequent writes fail with
+// ErrWriterClosed.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,222 @@
+package jlo_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GELFEncoder(t *testing.T) {
+
+	tests := map[string]struct {
+		Logger   func(l *jlo.Logger) *jlo.Logger
+		Log      func(l *jlo.Logger)
+		Expected string
+	}{
+		"simple": {
+			Log: func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: `{
+				"version":       "1.1",
+				"host":          "host",
+				"short_message": "I'm real",
+				"timestamp":     1533246536.8563395,
+				"level":         6
+			}`,
+		},
+		"fields": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithFields(jlo.Entry{
+					"@request_id": "e44c2a9",
+					"count":       42,
+					"id":          "reserved",
+					"my key":      true,
+					"disabled":    false,
+					"error":       errors.New("I'm broken"),
+					"chain":       []string{"I'm", "real"},
+					"nested":      jlo.Entry{"a": 1},
+					"nil":         nil,
+				})
+			},
+			Log: func(l *jlo.Logger) { l.Errorf("I'm real") },
+			Expected: `{
+				"version":       "1.1",
+				"host":          "host",
+				"short_message": "I'm real",
+				"timestamp":     1533246536.8563395,
+				"level":         3,
+				"_request_id":   "e44c2a9",
+				"_count":        42,
+				"_id_":          "reserved",
+				"_my_key":       1,
+				"_disabled":     0,
+				"_error":        "I'm broken",
+				"_chain":        "[\"I'm\",\"real\"]",
+				"_nested":       "{\"a\":1}",
+				"_nil":          "null"
+			}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf, jlo.WithEncoder(jlo.GELFEncoder{Host: "host"}))
+			if test.Logger != nil {
+				l = test.Logger(l)
+			}
+
+			test.Log(l)
+			assert.JSONEq(t, test.Expected, buf.String())
+		})
+	}
+}
+
+// readGELFDatagram reads a message from the UDP connection, reassembling
+// chunks and decompressing it if necessary
+func readGELFDatagram(t *testing.T, conn net.PacketConn) string {
+	var chunks [][]byte
+	var msg []byte
+	for {
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		buf = buf[:n]
+
+		if n < 2 || buf[0] != 0x1e || buf[1] != 0x0f {
+			msg = buf
+			break
+		}
+
+		if chunks == nil {
+			chunks = make([][]byte, buf[11])
+		}
+		chunks[buf[10]] = buf[12:]
+
+		complete := true
+		for _, c := range chunks {
+			complete = complete && c != nil
+		}
+		if complete {
+			msg = bytes.Join(chunks, nil)
+			break
+		}
+	}
+
+	if len(msg) >= 2 && msg[0] == 0x1f && msg[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(msg))
+		require.NoError(t, err)
+		msg, err = io.ReadAll(zr)
+		require.NoError(t, err)
+	}
+	return string(msg)
+}
+
+func Test_GELFWriter_UDP(t *testing.T) {
+	long := strings.Repeat("I'm real ", 1000)
+
+	tests := map[string]struct {
+		Config  jlo.GELFConfig
+		Message string
+	}{
+		"plain":              {Message: "I'm real"},
+		"compressed":         {Config: jlo.GELFConfig{Compress: true}, Message: "I'm real"},
+		"chunked":            {Message: long},
+		"chunked compressed": {Config: jlo.GELFConfig{Compress: true, ChunkSize: 100}, Message: long},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer conn.Close()
+
+			test.Config.Network = "udp"
+			test.Config.Address = conn.LocalAddr().String()
+			w, err := jlo.NewGELFWriter(test.Config)
+			require.NoError(t, err)
+			defer w.Close()
+
+			l := jlo.NewLogger(w, jlo.WithEncoder(jlo.GELFEncoder{Host: "host"}))
+			l.Infof(test.Message)
+
+			assert.JSONEq(t, `{
+				"version":       "1.1",
+				"host":          "host",
+				"short_message": "`+test.Message+`",
+				"timestamp":     1533246536.8563395,
+				"level":         6
+			}`, readGELFDatagram(t, conn))
+		})
+	}
+}
+
+func Test_GELFWriter_UDP_TooManyChunks(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := jlo.NewGELFWriter(jlo.GELFConfig{Network: "udp", Address: conn.LocalAddr().String(), ChunkSize: 13})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write(bytes.Repeat([]byte("x"), 129))
+	assert.Error(t, err)
+}
+
+func Test_GELFWriter_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	w, err := jlo.NewGELFWriter(jlo.GELFConfig{Network: "tcp", Address: ln.Addr().String()})
+	require.NoError(t, err)
+	defer w.Close()
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l := jlo.NewLogger(w, jlo.WithEncoder(jlo.GELFEncoder{Host: "host"}))
+	l.Infof("first")
+	l.Warnf("second")
+
+	r := bufio.NewReader(conn)
+	for _, expected := range []string{
+		`{"version":"1.1","host":"host","short_message":"first","timestamp":1533246536.8563395,"level":6}`,
+		`{"version":"1.1","host":"host","short_message":"second","timestamp":1533246536.8563395,"level":4}`,
+	} {
+		msg, err := r.ReadString(0)
+		require.NoError(t, err)
+		assert.Equal(t, expected+"\x00", msg)
+	}
+}
+
+func Test_NewGELFWriter_UnsupportedNetwork(t *testing.T) {
+	_, err := jlo.NewGELFWriter(jlo.GELFConfig{Network: "unix", Address: "/dev/log"})
+	assert.Error(t, err)
+}
+
+func Test_GELFWriter_Closed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := jlo.NewGELFWriter(jlo.GELFConfig{Network: "udp", Address: conn.LocalAddr().String()})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("I'm real\n"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+}
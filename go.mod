@@ -1,8 +1,15 @@
 module github.com/dcmn-com/jlo
 
-go 1.13
+go 1.21
 
 require (
+	github.com/golang/snappy v1.0.0
 	github.com/mailru/easyjson v0.7.0
 	github.com/stretchr/testify v1.4.0
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
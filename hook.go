@@ -0,0 +1,114 @@
+package jlo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrDropEntry is returned by hooks to prevent the entry from being written
+var ErrDropEntry = errors.New("drop entry")
+
+// Hook intercepts log entries before they are encoded. Hooks may read and
+// modify the entry, forward it elsewhere or drop it by returning
+// ErrDropEntry. Other errors are reported to the error handler of the logger
+// and the entry is written nevertheless. Hooks must not retain the entry and
+// must not log to the logger they are added to.
+type Hook interface {
+	// Levels returns the log levels the hook fires for
+	Levels() []LogLevel
+	// Fire is called for every entry on one of the hook levels
+	Fire(e *EntryView) error
+}
+
+// AllLevels returns all log levels, for hooks which fire on every entry
+func AllLevels() []LogLevel {
+	return []LogLevel{DebugLevel, InfoLevel, WarningLevel, ErrorLevel, FatalLevel, PanicLevel}
+}
+
+// levelHooks holds the hooks of a logger by log level
+type levelHooks [PanicLevel + 1][]Hook
+
+// with returns a copy of the hooks with h added for its levels
+func (hs levelHooks) with(h Hook) levelHooks {
+	for _, level := range h.Levels() {
+		if level <= UnknownLevel || level > PanicLevel {
+			continue
+		}
+		// copy on append, as the hooks are shared with clones
+		hs[level] = append(hs[level][:len(hs[level]):len(hs[level])], h)
+	}
+	return hs
+}
+
+// WithHooks returns an option which adds the hooks to the logger
+func WithHooks(hooks ...Hook) Option {
+	return func(l *Logger) {
+		for _, h := range hooks {
+			l.hooks = l.hooks.with(h)
+		}
+	}
+}
+
+// AddHook adds a hook to the logger. Loggers created with WithField and the
+// like inherit the hooks of the logger at the time of their creation.
+func (l *Logger) AddHook(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = l.hooks.with(h)
+}
+
+// fireHooks calls the hooks of the entry level in the order they were added.
+// It reports whether the entry should be written.
+func (l *Logger) fireHooks(e *EntryView) bool {
+	if e.level <= UnknownLevel || e.level > PanicLevel {
+		return true
+	}
+
+	for _, h := range l.hooks[e.level] {
+		if err := h.Fire(e); err != nil {
+			if errors.Is(err, ErrDropEntry) {
+				return false
+			}
+			l.handleError(fmt.Errorf("hook %T: %w", h, err))
+		}
+	}
+	return true
+}
+
+// ErrorHandler handles errors which occur while logging
+type ErrorHandler func(err error)
+
+// defaultErrorHandler writes the error to stderr
+func defaultErrorHandler(err error) {
+	fmt.Fprintf(os.Stderr, "jlo: %v\n", err)
+}
+
+// SetErrorHandler changes the handler of errors which occur while logging. A
+// nil handler restores the default, which writes the errors to stderr.
+func (l *Logger) SetErrorHandler(h ErrorHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errorHandler = h
+}
+
+// reportError passes err to the error handler of the logger. Unlike
+// handleError it may be called without holding l.mu.
+func (l *Logger) reportError(err error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	l.handleError(err)
+}
+
+// handleError passes err to the error handler of the logger. The caller must
+// hold l.mu.
+func (l *Logger) handleError(err error) {
+	if l.errorHandler == nil {
+		defaultErrorHandler(err)
+		return
+	}
+	l.errorHandler(err)
+}
@@ -0,0 +1,173 @@
+package jlo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// funcHook fires fn on the given levels
+type funcHook struct {
+	levels []jlo.LogLevel
+	fn     func(e *jlo.EntryView) error
+}
+
+func (h funcHook) Levels() []jlo.LogLevel {
+	return h.levels
+}
+
+func (h funcHook) Fire(e *jlo.EntryView) error {
+	return h.fn(e)
+}
+
+func Test_Logger_AddHook(t *testing.T) {
+
+	tests := map[string]struct {
+		Hook     funcHook
+		Log      func(l *jlo.Logger)
+		Expected string
+	}{
+		"set field": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				e.SetField("@request_id", "e44c2a9")
+				return nil
+			}},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: `{"@level":"info","@message":"I'm real","@request_id":"e44c2a9","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+		"replace logger field": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				e.SetField("password", "***")
+				return nil
+			}},
+			Log:      func(l *jlo.Logger) { l.WithField("password", "secret").Infof("I'm real") },
+			Expected: `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z","password":"***"}`,
+		},
+		"delete logger field": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				e.DeleteField("password")
+				if _, ok := e.Field("password"); ok {
+					return errors.New("field not deleted")
+				}
+				return nil
+			}},
+			Log:      func(l *jlo.Logger) { l.WithFields(jlo.Entry{"password": "secret", "user": "kim"}).Infof("I'm real") },
+			Expected: `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z","user":"kim"}`,
+		},
+		"delete missing field": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				e.DeleteField("password")
+				return nil
+			}},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+		"set message": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				e.SetMessage("[redacted] " + e.Message())
+				return nil
+			}},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm %s", "real") },
+			Expected: `{"@level":"info","@message":"[redacted] I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+		"drop entry": {
+			Hook: funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+				return fmt.Errorf("sampled out: %w", jlo.ErrDropEntry)
+			}},
+			Log: func(l *jlo.Logger) { l.Infof("I'm real") },
+		},
+		"other level": {
+			Hook: funcHook{levels: []jlo.LogLevel{jlo.ErrorLevel}, fn: func(e *jlo.EntryView) error {
+				return jlo.ErrDropEntry
+			}},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetErrorHandler(func(err error) { t.Error(err) })
+			l.AddHook(test.Hook)
+
+			test.Log(l)
+			if test.Expected == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+			assert.Equal(t, test.Expected+"\n", buf.String())
+		})
+	}
+}
+
+func Test_Logger_AddHook_InheritedByClones(t *testing.T) {
+	var fired []string
+	hook := funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+		fired = append(fired, e.Message())
+		return nil
+	}}
+
+	l := jlo.NewLogger(bytes.NewBuffer(nil), jlo.WithHooks(hook))
+	child := l.WithField("service", "api")
+	l.AddHook(hook)
+
+	child.Infof("child")
+	l.Infof("parent")
+	assert.Equal(t, []string{"child", "parent", "parent"}, fired)
+}
+
+func Test_Logger_AddHook_Order(t *testing.T) {
+	var fired []string
+	hook := func(name string) funcHook {
+		return funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+			fired = append(fired, name)
+			return nil
+		}}
+	}
+
+	l := jlo.NewLogger(bytes.NewBuffer(nil))
+	l.AddHook(hook("first"))
+	l.AddHook(hook("second"))
+
+	l.Infof("I'm real")
+	assert.Equal(t, []string{"first", "second"}, fired)
+}
+
+func Test_Logger_SetErrorHandler(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.AddHook(funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+		return errors.New("I'm broken")
+	}})
+
+	var errs []error
+	l.SetErrorHandler(func(err error) { errs = append(errs, err) })
+	l.WithField("service", "api").Infof("I'm real")
+
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "hook jlo_test.funcHook: I'm broken", errs[0].Error())
+	}
+	// failing hooks don't prevent the entry from being written
+	assert.Contains(t, buf.String(), `"@message":"I'm real"`)
+}
+
+func Test_EntryView_Context(t *testing.T) {
+	type key struct{}
+	var values []interface{}
+	l := jlo.NewLogger(bytes.NewBuffer(nil), jlo.WithHooks(funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+		values = append(values, e.Context().Value(key{}))
+		return nil
+	}}))
+
+	l.InfoContext(context.WithValue(context.Background(), key{}, "I'm real"), "I'm real")
+	l.Infof("I'm real")
+	assert.Equal(t, []interface{}{"I'm real", nil}, values)
+}
@@ -0,0 +1,669 @@
+package jlo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of HTTPConfig
+const (
+	DefaultHTTPBatchSize      = 1000
+	DefaultHTTPBatchBytes     = 1 << 20
+	DefaultHTTPFlushInterval  = time.Second
+	DefaultHTTPPendingBatches = 8
+	DefaultHTTPMaxRetries     = 5
+	DefaultHTTPMinBackoff     = 100 * time.Millisecond
+	DefaultHTTPMaxBackoff     = 10 * time.Second
+	DefaultHTTPFlushTimeout   = 5 * time.Second
+)
+
+// spillFileExt is the extension of batches spilled to disk
+const spillFileExt = ".batch"
+
+// BatchEntry is an encoded log entry without trailing newline
+type BatchEntry struct {
+	Level LogLevel
+	Data  []byte
+}
+
+// BatchEncoder encodes batches of log entries into request bodies
+type BatchEncoder interface {
+	// ContentType returns the content type of the request body
+	ContentType() string
+	// AppendBatch appends the request body for the batch of log entries to dst
+	AppendBatch(dst []byte, entries []BatchEntry) ([]byte, error)
+}
+
+// NDJSONBatchEncoder encodes batches as newline delimited entries
+type NDJSONBatchEncoder struct{}
+
+// ContentType implements BatchEncoder
+func (NDJSONBatchEncoder) ContentType() string {
+	return "application/x-ndjson"
+}
+
+// AppendBatch implements BatchEncoder
+func (NDJSONBatchEncoder) AppendBatch(dst []byte, entries []BatchEntry) ([]byte, error) {
+	for _, e := range entries {
+		dst = append(dst, e.Data...)
+		dst = append(dst, '\n')
+	}
+	return dst, nil
+}
+
+// HTTPConfig configures an HTTPWriter
+type HTTPConfig struct {
+	// URL is the endpoint batches are sent to
+	URL string
+	// Method defaults to POST
+	Method string
+	// Header is added to all requests, e.g. for authorization
+	Header http.Header
+	// Client defaults to a client with a timeout of 10 seconds
+	Client *http.Client
+	// Encoder defaults to NDJSONBatchEncoder
+	Encoder BatchEncoder
+	// Compress enables gzip compression of request bodies
+	Compress bool
+
+	// BatchSize is the maximum number of entries per batch. It defaults to
+	// DefaultHTTPBatchSize.
+	BatchSize int
+	// BatchBytes is the maximum size of the entries of a batch. It defaults to
+	// DefaultHTTPBatchBytes.
+	BatchBytes int
+	// FlushInterval is the interval in which incomplete batches are sent. It
+	// defaults to DefaultHTTPFlushInterval.
+	FlushInterval time.Duration
+	// PendingBatches is the number of batches waiting to be sent, before
+	// further batches are dropped. It defaults to DefaultHTTPPendingBatches.
+	PendingBatches int
+
+	// MaxRetries is the number of retries after network errors and 429 or 5xx
+	// responses. It defaults to DefaultHTTPMaxRetries, negative values disable
+	// retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, which doubles with each
+	// retry. It defaults to DefaultHTTPMinBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff limits the delay between retries. It defaults to
+	// DefaultHTTPMaxBackoff.
+	MaxBackoff time.Duration
+	// FlushTimeout limits how long Sync and Close wait for pending batches.
+	// Batches not sent by then are spilled or dropped without further
+	// retries. It defaults to DefaultHTTPFlushTimeout.
+	FlushTimeout time.Duration
+
+	// SpillDir is the directory batches are stored in when all retries
+	// failed. They are sent again once the endpoint accepts requests. Empty
+	// disables spilling.
+	SpillDir string
+	// MaxSpillBytes limits the size of the spilled batches. Zero means no
+	// limit.
+	MaxSpillBytes int64
+}
+
+// HTTPStats are the counters of an HTTPWriter
+type HTTPStats struct {
+	// Sent is the number of entries accepted by the endpoint
+	Sent uint64
+	// Failed is the number of entries rejected by the endpoint or not sent
+	// after all retries and not spilled
+	Failed uint64
+	// Dropped is the number of entries discarded because too many batches
+	// were pending, the spill directory was full or they were not sent within
+	// the flush timeout of Sync or Close without spilling
+	Dropped uint64
+	// Spilled is the number of entries stored in the spill directory
+	Spilled uint64
+}
+
+// httpBatch is a batch of encoded entries
+type httpBatch struct {
+	data   []byte
+	ends   []int
+	levels []LogLevel
+}
+
+// entries returns the entries of the batch
+func (b *httpBatch) entries() []BatchEntry {
+	entries := make([]BatchEntry, len(b.ends))
+	start := 0
+	for i, end := range b.ends {
+		entries[i] = BatchEntry{Level: b.levels[i], Data: b.data[start:end]}
+		start = end
+	}
+	return entries
+}
+
+// httpRequest is a batch to send and/or a flush to acknowledge. Sending is
+// given up once abort is closed.
+type httpRequest struct {
+	batch   *httpBatch
+	abort   <-chan struct{}
+	flushed chan struct{}
+}
+
+// HTTPWriter sends log entries in batches to an HTTP endpoint. Batches are
+// sent in the background when they are full or the flush interval passed.
+type HTTPWriter struct {
+	config HTTPConfig
+
+	mu     sync.Mutex
+	closed bool
+	batch  *httpBatch
+	// abort is passed on to requests and closed when Sync or Close give up
+	// on them
+	abort chan struct{}
+
+	// requests holds full batches, flushes takes the current batch of Flush
+	// and Close. Neither is closed, so they can be sent to without holding
+	// w.mu.
+	requests chan httpRequest
+	flushes  chan httpRequest
+	stop     chan struct{}
+	done     chan struct{}
+
+	sent, failed, dropped, spilled atomic.Uint64
+}
+
+// NewHTTPWriter creates a new HTTP writer and starts its background goroutines.
+// Close must be called to send pending entries and stop them.
+func NewHTTPWriter(config HTTPConfig) (*HTTPWriter, error) {
+	if config.URL == "" {
+		return nil, errors.New("missing url")
+	}
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.Encoder == nil {
+		config.Encoder = NDJSONBatchEncoder{}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultHTTPBatchSize
+	}
+	if config.BatchBytes <= 0 {
+		config.BatchBytes = DefaultHTTPBatchBytes
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultHTTPFlushInterval
+	}
+	if config.PendingBatches <= 0 {
+		config.PendingBatches = DefaultHTTPPendingBatches
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultHTTPMaxRetries
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultHTTPMinBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultHTTPMaxBackoff
+	}
+	if config.FlushTimeout <= 0 {
+		config.FlushTimeout = DefaultHTTPFlushTimeout
+	}
+	if config.SpillDir != "" {
+		if err := os.MkdirAll(config.SpillDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &HTTPWriter{
+		config:   config,
+		batch:    &httpBatch{},
+		abort:    make(chan struct{}),
+		requests: make(chan httpRequest, config.PendingBatches),
+		flushes:  make(chan httpRequest),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	go w.tick()
+	return w, nil
+}
+
+// Write adds p to the current batch as an entry of UnknownLevel
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(UnknownLevel, p)
+}
+
+// WriteLevel adds p to the current batch. A trailing newline is removed.
+func (w *HTTPWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	b := w.batch
+	b.data = append(b.data, bytes.TrimSuffix(p, []byte{'\n'})...)
+	b.ends = append(b.ends, len(b.data))
+	b.levels = append(b.levels, level)
+
+	if len(b.ends) >= w.config.BatchSize || len(b.data) >= w.config.BatchBytes {
+		w.enqueue()
+	}
+	return len(p), nil
+}
+
+// enqueue hands the current batch over to the background goroutine, dropping
+// it if too many batches are pending. The caller must hold w.mu.
+func (w *HTTPWriter) enqueue() {
+	b := w.batch
+	if len(b.ends) == 0 {
+		return
+	}
+	w.batch = &httpBatch{}
+
+	select {
+	case w.requests <- httpRequest{batch: b, abort: w.abort}:
+	default:
+		w.dropped.Add(uint64(len(b.ends)))
+	}
+}
+
+// Flush sends the current batch and waits until all pending batches are sent
+func (w *HTTPWriter) Flush(ctx context.Context) error {
+	return w.flush(ctx, false)
+}
+
+// flush sends the current batch and waits until all pending batches are sent.
+// If giveUp is set and ctx is done before, the pending batches are spilled or
+// dropped without further retries.
+func (w *HTTPWriter) flush(ctx context.Context, giveUp bool) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	req := w.takeBatch()
+	req.flushed = make(chan struct{})
+	w.mu.Unlock()
+
+	select {
+	case w.flushes <- req:
+	case <-w.done:
+		w.dropped.Add(uint64(len(req.batch.ends)))
+		return nil
+	case <-ctx.Done():
+		if !giveUp {
+			w.dropped.Add(uint64(len(req.batch.ends)))
+			return ctx.Err()
+		}
+		// the background goroutine is quick to take the request after
+		// giving up on the pending ones
+		w.giveUp()
+		select {
+		case w.flushes <- req:
+		case <-w.done:
+			w.dropped.Add(uint64(len(req.batch.ends)))
+			return ctx.Err()
+		}
+		<-req.flushed
+		return ctx.Err()
+	}
+
+	select {
+	case <-req.flushed:
+		return nil
+	case <-ctx.Done():
+		if giveUp {
+			w.giveUp()
+			<-req.flushed
+		}
+		return ctx.Err()
+	}
+}
+
+// Sync sends all pending batches, so that Fatalf and Panicf ship the last
+// entries before exiting. Batches not sent within the flush timeout are
+// spilled or dropped.
+func (w *HTTPWriter) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.FlushTimeout)
+	defer cancel()
+
+	return w.flush(ctx, true)
+}
+
+// Close sends all pending batches and stops the background goroutines.
+// Batches not sent within the flush timeout are spilled or dropped. Subsequent
+// writes fail with ErrWriterClosed.
+func (w *HTTPWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		<-w.done
+		return nil
+	}
+	w.closed = true
+	req := w.takeBatch()
+	w.mu.Unlock()
+
+	timeout := time.NewTimer(w.config.FlushTimeout)
+	defer timeout.Stop()
+
+	select {
+	case w.flushes <- req:
+	case <-timeout.C:
+		w.giveUp()
+		w.flushes <- req
+	}
+	close(w.stop)
+
+	select {
+	case <-w.done:
+	case <-timeout.C:
+		w.giveUp()
+		<-w.done
+	}
+	return nil
+}
+
+// takeBatch returns a request for the current batch and starts a new one. The
+// caller must hold w.mu.
+func (w *HTTPWriter) takeBatch() httpRequest {
+	req := httpRequest{batch: w.batch, abort: w.abort}
+	w.batch = &httpBatch{}
+	return req
+}
+
+// giveUp makes the background goroutine spill or drop all pending batches
+// without further retries
+func (w *HTTPWriter) giveUp() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	close(w.abort)
+	w.abort = make(chan struct{})
+}
+
+// Stats returns the counters of the writer
+func (w *HTTPWriter) Stats() HTTPStats {
+	return HTTPStats{
+		Sent:    w.sent.Load(),
+		Failed:  w.failed.Load(),
+		Dropped: w.dropped.Load(),
+		Spilled: w.spilled.Load(),
+	}
+}
+
+// tick sends incomplete batches in the flush interval
+func (w *HTTPWriter) tick() {
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.closed {
+				w.mu.Unlock()
+				return
+			}
+			if len(w.batch.ends) > 0 {
+				w.enqueue()
+			} else if w.config.SpillDir != "" {
+				// retry spilled batches even if nothing is logged
+				select {
+				case w.requests <- httpRequest{abort: w.abort}:
+				default:
+				}
+			}
+			w.mu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// run sends batches until the writer is closed
+func (w *HTTPWriter) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case req := <-w.requests:
+			w.handle(req, nil)
+		case req := <-w.flushes:
+			// batches queued before the flush are sent first and given up
+			// together with it
+			w.drain(req.abort)
+			w.handle(req, nil)
+		case <-w.stop:
+			w.drain(nil)
+			return
+		}
+	}
+}
+
+// drain handles all currently queued requests, giving up on them once either
+// their own or the given abort channel is closed
+func (w *HTTPWriter) drain(abort <-chan struct{}) {
+	for {
+		select {
+		case req := <-w.requests:
+			w.handle(req, abort)
+		default:
+			return
+		}
+	}
+}
+
+// handle sends the batch of the request or resends spilled batches if it has
+// none
+func (w *HTTPWriter) handle(req httpRequest, abort <-chan struct{}) {
+	ctx, cancel := abortContext(req.abort, abort)
+	defer cancel()
+
+	if req.batch != nil && len(req.batch.ends) > 0 {
+		w.sendBatch(ctx, req.batch)
+	} else {
+		w.resendSpilled(ctx)
+	}
+	if req.flushed != nil {
+		close(req.flushed)
+	}
+}
+
+// abortContext returns a context which is canceled once one of the channels
+// is closed
+func abortContext(a, b <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-a:
+		case <-b:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// sendBatch sends the batch with retries and spills it to disk if it could not
+// be sent. Batches given up on are spilled or dropped.
+func (w *HTTPWriter) sendBatch(ctx context.Context, b *httpBatch) {
+	n := uint64(len(b.ends))
+
+	body, err := w.config.Encoder.AppendBatch(nil, b.entries())
+	if err != nil {
+		w.failed.Add(n)
+		return
+	}
+
+	retry, err := w.sendWithRetries(ctx, body)
+	switch {
+	case err == nil:
+		w.sent.Add(n)
+		w.resendSpilled(ctx)
+	case retry && w.config.SpillDir != "":
+		w.spill(body, n)
+	case ctx.Err() != nil:
+		w.dropped.Add(n)
+	default:
+		w.failed.Add(n)
+	}
+}
+
+// sendWithRetries sends the body, retrying with exponential backoff until ctx
+// is canceled. It reports whether the last error is temporary.
+func (w *HTTPWriter) sendWithRetries(ctx context.Context, body []byte) (bool, error) {
+	backoff := w.config.MinBackoff
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+
+		retry, err := w.send(ctx, body)
+		if err == nil || !retry || attempt >= w.config.MaxRetries {
+			return retry, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return true, ctx.Err()
+		}
+		if backoff *= 2; backoff > w.config.MaxBackoff {
+			backoff = w.config.MaxBackoff
+		}
+	}
+}
+
+// send sends the body once. It reports whether the request should be retried
+// if it failed.
+func (w *HTTPWriter) send(ctx context.Context, body []byte) (bool, error) {
+	var r io.Reader = bytes.NewReader(body)
+	if w.config.Compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return false, err
+		}
+		r = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.config.Method, w.config.URL, r)
+	if err != nil {
+		return false, err
+	}
+	for key, values := range w.config.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", w.config.Encoder.ContentType())
+	if w.config.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := w.config.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// spill stores the body of a batch with n entries in the spill directory. The
+// file name consists of the current time and the number of entries.
+func (w *HTTPWriter) spill(body []byte, n uint64) {
+	if w.config.MaxSpillBytes > 0 && w.spillSize()+int64(len(body)) > w.config.MaxSpillBytes {
+		w.dropped.Add(n)
+		return
+	}
+
+	name := filepath.Join(w.config.SpillDir,
+		strconv.FormatInt(time.Now().UnixNano(), 10)+"-"+strconv.FormatUint(n, 10)+spillFileExt)
+	if err := os.WriteFile(name, body, 0644); err != nil {
+		w.failed.Add(n)
+		return
+	}
+	w.spilled.Add(n)
+}
+
+// spillFiles returns the names of spilled batches from oldest to newest
+func (w *HTTPWriter) spillFiles() []string {
+	if w.config.SpillDir == "" {
+		return nil
+	}
+
+	names, _ := filepath.Glob(filepath.Join(w.config.SpillDir, "*"+spillFileExt))
+	sort.Strings(names)
+	return names
+}
+
+// spillSize returns the total size of spilled batches
+func (w *HTTPWriter) spillSize() int64 {
+	var size int64
+	for _, name := range w.spillFiles() {
+		if info, err := os.Stat(name); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// resendSpilled sends spilled batches until one fails or ctx is canceled
+func (w *HTTPWriter) resendSpilled(ctx context.Context) {
+	for _, name := range w.spillFiles() {
+		if ctx.Err() != nil {
+			return
+		}
+		body, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+
+		retry, err := w.send(ctx, body)
+		if err != nil && retry {
+			return
+		}
+
+		n := spillFileEntries(name)
+		if err == nil {
+			w.sent.Add(n)
+		} else {
+			w.failed.Add(n)
+		}
+		os.Remove(name)
+	}
+}
+
+// spillFileEntries returns the number of entries of a spilled batch encoded in
+// its file name
+func spillFileEntries(name string) uint64 {
+	base := strings.TrimSuffix(filepath.Base(name), spillFileExt)
+	i := strings.LastIndexByte(base, '-')
+	if i < 0 {
+		return 0
+	}
+
+	n, _ := strconv.ParseUint(base[i+1:], 10, 64)
+	return n
+}
@@ -0,0 +1,415 @@
+package jlo_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchServer records the bodies of all requests and responds with the status
+// returned by the status function
+type batchServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	bodies  []string
+	headers []http.Header
+	status  func(attempt int) int
+}
+
+func newBatchServer(t *testing.T, status func(attempt int) int) *batchServer {
+	s := &batchServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(b))
+		s.headers = append(s.headers, r.Header)
+		attempt := len(s.bodies)
+		s.mu.Unlock()
+
+		if s.status != nil {
+			w.WriteHeader(s.status(attempt))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *batchServer) requests() ([]string, []http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...), append([]http.Header(nil), s.headers...)
+}
+
+func Test_HTTPWriter_BatchSize(t *testing.T) {
+	s := newBatchServer(t, nil)
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{
+		URL:       s.URL,
+		Header:    http.Header{"Authorization": {"Bearer real"}},
+		BatchSize: 2,
+	})
+	require.NoError(t, err)
+	defer w.Close()
+
+	l := jlo.NewLogger(w)
+	l.Infof("first")
+	l.Infof("second")
+	l.Infof("third")
+	require.NoError(t, w.Flush(context.Background()))
+
+	bodies, headers := s.requests()
+	require.Len(t, bodies, 2)
+	assert.Equal(t, 2, strings.Count(bodies[0], "\n"))
+	assert.Contains(t, bodies[0], `"@message":"first"`)
+	assert.Contains(t, bodies[0], `"@message":"second"`)
+	assert.Contains(t, bodies[1], `"@message":"third"`)
+	assert.Equal(t, "application/x-ndjson", headers[0].Get("Content-Type"))
+	assert.Equal(t, "Bearer real", headers[0].Get("Authorization"))
+	assert.Equal(t, jlo.HTTPStats{Sent: 3}, w.Stats())
+}
+
+func Test_HTTPWriter_FlushInterval(t *testing.T) {
+	s := newBatchServer(t, nil)
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, FlushInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("I'm real\n"))
+
+	assert.Eventually(t, func() bool {
+		bodies, _ := s.requests()
+		return len(bodies) == 1 && bodies[0] == "I'm real\n"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_HTTPWriter_Compress(t *testing.T) {
+	s := newBatchServer(t, nil)
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, Compress: true})
+	require.NoError(t, err)
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Close())
+
+	bodies, headers := s.requests()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "I'm real\n", bodies[0])
+	assert.Equal(t, "gzip", headers[0].Get("Content-Encoding"))
+}
+
+func Test_HTTPWriter_Retries(t *testing.T) {
+
+	tests := map[string]struct {
+		Status   func(attempt int) int
+		Requests int
+		Stats    jlo.HTTPStats
+	}{
+		"server errors": {
+			Status: func(attempt int) int {
+				if attempt < 3 {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusOK
+			},
+			Requests: 3,
+			Stats:    jlo.HTTPStats{Sent: 1},
+		},
+		"too many requests": {
+			Status: func(attempt int) int {
+				if attempt < 2 {
+					return http.StatusTooManyRequests
+				}
+				return http.StatusNoContent
+			},
+			Requests: 2,
+			Stats:    jlo.HTTPStats{Sent: 1},
+		},
+		"retries exhausted": {
+			Status:   func(attempt int) int { return http.StatusBadGateway },
+			Requests: 4,
+			Stats:    jlo.HTTPStats{Failed: 1},
+		},
+		"client error": {
+			Status:   func(attempt int) int { return http.StatusBadRequest },
+			Requests: 1,
+			Stats:    jlo.HTTPStats{Failed: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newBatchServer(t, test.Status)
+			w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, MaxRetries: 3, MinBackoff: time.Millisecond})
+			require.NoError(t, err)
+
+			w.Write([]byte("I'm real\n"))
+			require.NoError(t, w.Close())
+
+			bodies, _ := s.requests()
+			assert.Len(t, bodies, test.Requests)
+			assert.Equal(t, test.Stats, w.Stats())
+		})
+	}
+}
+
+func Test_HTTPWriter_NetworkError(t *testing.T) {
+	s := newBatchServer(t, nil)
+	s.Close()
+
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, MaxRetries: 2, MinBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Close())
+	assert.Equal(t, jlo.HTTPStats{Failed: 1}, w.Stats())
+}
+
+func Test_HTTPWriter_Spill(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	s := newBatchServer(t, func(attempt int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+
+	dir := t.TempDir()
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, MaxRetries: -1, SpillDir: dir})
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	w.Write([]byte("second\n"))
+	require.NoError(t, w.Flush(context.Background()))
+	assert.Equal(t, jlo.HTTPStats{Spilled: 2}, w.Stats())
+	assert.Len(t, dirFiles(t, dir), 1)
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+
+	w.Write([]byte("third\n"))
+	require.NoError(t, w.Flush(context.Background()))
+
+	bodies, _ := s.requests()
+	assert.Equal(t, []string{"first\nsecond\n", "third\n", "first\nsecond\n"}, bodies)
+	assert.Equal(t, jlo.HTTPStats{Sent: 3, Spilled: 2}, w.Stats())
+	assert.Empty(t, dirFiles(t, dir))
+}
+
+func Test_HTTPWriter_Spill_ResentWithoutNewEntries(t *testing.T) {
+	s := newBatchServer(t, func(attempt int) int {
+		if attempt == 1 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+
+	dir := t.TempDir()
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, MaxRetries: -1, SpillDir: dir, FlushInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Flush(context.Background()))
+
+	assert.Eventually(t, func() bool {
+		return w.Stats().Sent == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, dirFiles(t, dir))
+}
+
+func Test_HTTPWriter_GivesUpAfterFlushTimeout(t *testing.T) {
+
+	tests := map[string]struct {
+		Spill    bool
+		Finish   func(w *jlo.HTTPWriter) error
+		Err      error
+		Expected jlo.HTTPStats
+	}{
+		"sync spills": {
+			Spill:    true,
+			Finish:   (*jlo.HTTPWriter).Sync,
+			Err:      context.DeadlineExceeded,
+			Expected: jlo.HTTPStats{Spilled: 3},
+		},
+		"sync drops": {
+			Finish:   (*jlo.HTTPWriter).Sync,
+			Err:      context.DeadlineExceeded,
+			Expected: jlo.HTTPStats{Dropped: 3},
+		},
+		"close spills": {
+			Spill:    true,
+			Finish:   (*jlo.HTTPWriter).Close,
+			Expected: jlo.HTTPStats{Spilled: 3},
+		},
+		"close drops": {
+			Finish:   (*jlo.HTTPWriter).Close,
+			Expected: jlo.HTTPStats{Dropped: 3},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newBatchServer(t, func(attempt int) int { return http.StatusServiceUnavailable })
+
+			config := jlo.HTTPConfig{
+				URL:          s.URL,
+				BatchSize:    2,
+				MinBackoff:   time.Hour,
+				FlushTimeout: 50 * time.Millisecond,
+			}
+			if test.Spill {
+				config.SpillDir = t.TempDir()
+			}
+			w, err := jlo.NewHTTPWriter(config)
+			require.NoError(t, err)
+			defer w.Close()
+
+			// the first batch waits for a retry, the last entry is the current
+			// batch
+			w.Write([]byte("first\n"))
+			w.Write([]byte("second\n"))
+			w.Write([]byte("third\n"))
+
+			start := time.Now()
+			assert.Equal(t, test.Err, test.Finish(w))
+			assert.True(t, time.Since(start) < time.Second)
+			assert.Equal(t, test.Expected, w.Stats())
+		})
+	}
+}
+
+func Test_HTTPWriter_MaxSpillBytes(t *testing.T) {
+	s := newBatchServer(t, func(attempt int) int { return http.StatusServiceUnavailable })
+
+	dir := t.TempDir()
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, MaxRetries: -1, SpillDir: dir, MaxSpillBytes: 10})
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Flush(context.Background()))
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Flush(context.Background()))
+
+	assert.Equal(t, jlo.HTTPStats{Spilled: 1, Dropped: 1}, w.Stats())
+	files := dirFiles(t, dir)
+	require.Len(t, files, 1)
+	b, err := os.ReadFile(dir + "/" + files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "I'm real\n", string(b))
+}
+
+func Test_HTTPWriter_PendingBatches(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	defer s.Close()
+
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, BatchSize: 1, PendingBatches: 1})
+	require.NoError(t, err)
+
+	w.Write([]byte("first\n"))
+	<-started
+	w.Write([]byte("second\n"))
+	w.Write([]byte("third\n"))
+	close(release)
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, jlo.HTTPStats{Sent: 2, Dropped: 1}, w.Stats())
+}
+
+func Test_HTTPWriter_Flush_DoesNotBlockWrites(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	defer s.Close()
+
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL, BatchSize: 1, PendingBatches: 1})
+	require.NoError(t, err)
+
+	w.Write([]byte("first\n"))
+	<-started
+	w.Write([]byte("second\n"))
+
+	flushed := make(chan error)
+	go func() { flushed <- w.Sync() }()
+	// give the flush time to block on the pending batches
+	time.Sleep(50 * time.Millisecond)
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("third\n"))
+		close(written)
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("write blocked by flush")
+	}
+
+	close(release)
+	require.NoError(t, <-flushed)
+	require.NoError(t, w.Close())
+}
+
+func Test_HTTPWriter_Closed(t *testing.T) {
+	s := newBatchServer(t, nil)
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: s.URL})
+	require.NoError(t, err)
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+
+	bodies, _ := s.requests()
+	assert.Equal(t, []string{"I'm real\n"}, bodies)
+
+	_, err = w.Write([]byte("I'm real\n"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+	assert.NoError(t, w.Flush(context.Background()))
+}
+
+func Test_NewHTTPWriter_MissingURL(t *testing.T) {
+	_, err := jlo.NewHTTPWriter(jlo.HTTPConfig{})
+	assert.Error(t, err)
+}
@@ -2,17 +2,14 @@
 package jlo
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"sync"
 	"time"
-
-	easyjson "github.com/mailru/easyjson"
 )
 
-//go:generate easyjson -no_std_marshalers $GOFILE
-
 const (
 	// FieldKeyLevel is the log level log field name
 	FieldKeyLevel = "@level"
@@ -22,21 +19,25 @@ const (
 	FieldKeyTime = "@timestamp"
 	// FieldKeyCommit is the commit log field name
 	FieldKeyCommit = "@commit"
+	// FieldKeyCaller is the caller file and line log field name
+	FieldKeyCaller = "@caller"
+	// FieldKeyFunction is the caller function log field name
+	FieldKeyFunction = "@function"
+	// FieldKeyStacktrace is the stack trace log field name
+	FieldKeyStacktrace = "@stacktrace"
+	// FieldKeyError is the error message log field name
+	FieldKeyError = "@error"
+	// FieldKeyErrorType is the error type log field name
+	FieldKeyErrorType = "@error_type"
+	// FieldKeyErrorChain is the wrapped error messages log field name
+	FieldKeyErrorChain = "@error_chain"
+	// FieldKeyErrorDetails is the structured error details log field name
+	FieldKeyErrorDetails = "@error_details"
 )
 
 // LogLevel represents a log level used by Logger type
 type LogLevel int
 
-func (lvl LogLevel) MarshalEasyJSON() ([]byte, error) {
-	s := lvl.String()
-	out := make([]byte, len(s)+2)
-
-	copy(out[1:len(out)-2], s[:])
-	out[0], out[len(out)-1] = '"', '"'
-
-	return out, nil
-}
-
 const (
 	// UnknownLevel means the log level could not be parsed
 	UnknownLevel LogLevel = iota
@@ -46,10 +47,12 @@ const (
 	InfoLevel
 	// WarningLevel logs messages on all levels except DebugLevel and InfoLevel
 	WarningLevel
-	// ErrorLevel logs messages on ErrorLevel and FatalLevel
+	// ErrorLevel logs messages on ErrorLevel, FatalLevel and PanicLevel
 	ErrorLevel
-	// FatalLevel logs messages on FatalLevel
+	// FatalLevel logs messages on FatalLevel and PanicLevel
 	FatalLevel
+	// PanicLevel logs messages on PanicLevel
+	PanicLevel
 )
 
 // String returns a string representation of the log level
@@ -63,8 +66,12 @@ func (l LogLevel) String() string {
 		return "warning"
 	case ErrorLevel:
 		return "error"
-	default:
+	case FatalLevel:
 		return "fatal"
+	case PanicLevel:
+		return "panic"
+	default:
+		return "unknown"
 	}
 }
 
@@ -76,11 +83,13 @@ func SetLogLevel(level LogLevel) {
 	logLevel = level
 }
 
+// Now returns the current time for loggers without a Clock set via SetClock.
+// Prefer Logger.SetClock over overwriting Now, as Now is shared by all loggers.
 var Now = func() time.Time {
 	return time.Now().UTC()
 }
 
-//easyjson:json
+// Entry is a set of log fields mapped by their name
 type Entry map[string]interface{}
 
 // Logger logs json formatted messages to a certain output destination
@@ -88,11 +97,30 @@ type Logger struct {
 	FieldKeyMsg   string
 	FieldKeyLevel string
 	FieldKeyTime  string
-	fields        Entry
+	fields        fields
 	mu            sync.RWMutex
 	logLevel      LogLevel
-	outMu         sync.Mutex
+	outMu         *sync.Mutex
 	out           io.Writer
+	exit          func(code int)
+
+	reportCaller   bool
+	reportFunction bool
+	callerSkip     int
+
+	stacktraceLevel LogLevel
+
+	clock         Clock
+	timePrecision time.Duration
+	timeLocation  *time.Location
+	timeEncoder   TimeEncoder
+	encoder       Encoder
+
+	hooks        levelHooks
+	errorHandler ErrorHandler
+	stats        *loggerStats
+
+	contextExtractors []ContextExtractor
 }
 
 // DefaultLogger returns a new default logger logging to stdout
@@ -100,24 +128,59 @@ func DefaultLogger() *Logger {
 	return NewLogger(os.Stdout)
 }
 
+// Option configures a Logger upon creation
+type Option func(*Logger)
+
+// WithEncoder returns an option which makes the logger use the given encoder
+func WithEncoder(enc Encoder) Option {
+	return func(l *Logger) {
+		l.encoder = enc
+	}
+}
+
 // NewLogger creates a new logger which will write to the passed in io.Writer
-func NewLogger(out io.Writer) *Logger {
-	return &Logger{
+func NewLogger(out io.Writer, opts ...Option) *Logger {
+	l := &Logger{
 		FieldKeyMsg:   FieldKeyMsg,
 		FieldKeyLevel: FieldKeyLevel,
 		FieldKeyTime:  FieldKeyTime,
-		fields:        make(Entry),
 		logLevel:      logLevel,
+		outMu:         &sync.Mutex{},
 		out:           out,
+		exit:          os.Exit,
+		stats:         &loggerStats{},
+	}
+
+	for _, opt := range opts {
+		opt(l)
 	}
+	return l
 }
 
-// Fatalf logs a message on FatalLevel
+// Panicf logs a message on PanicLevel and panics afterwards with the formatted
+// message
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	l.log(context.Background(), PanicLevel, format, args...)
+	l.flush()
+
+	if len(args) > 0 {
+		panic(fmt.Sprintf(format, args...))
+	}
+	panic(format)
+}
+
+// Fatalf logs a message on FatalLevel, flushes the output and terminates the
+// program by calling the exit function, which defaults to os.Exit(1)
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	l.log(FatalLevel, format, args...)
+	l.log(context.Background(), FatalLevel, format, args...)
+	l.flush()
+	l.exit(1)
 }
 
 // Errorf logs a messages on ErrorLevel
@@ -126,7 +189,7 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 	defer l.mu.RUnlock()
 
 	if l.logLevel <= ErrorLevel {
-		l.log(ErrorLevel, format, args...)
+		l.log(context.Background(), ErrorLevel, format, args...)
 	}
 }
 
@@ -136,7 +199,7 @@ func (l *Logger) Warnf(format string, args ...interface{}) {
 	defer l.mu.RUnlock()
 
 	if l.logLevel <= WarningLevel {
-		l.log(WarningLevel, format, args...)
+		l.log(context.Background(), WarningLevel, format, args...)
 	}
 }
 
@@ -146,7 +209,7 @@ func (l *Logger) Infof(format string, args ...interface{}) {
 	defer l.mu.RUnlock()
 
 	if l.logLevel <= InfoLevel {
-		l.log(InfoLevel, format, args...)
+		l.log(context.Background(), InfoLevel, format, args...)
 	}
 }
 
@@ -156,7 +219,7 @@ func (l *Logger) Debugf(format string, args ...interface{}) {
 	defer l.mu.RUnlock()
 
 	if l.logLevel <= DebugLevel {
-		l.log(DebugLevel, format, args...)
+		l.log(context.Background(), DebugLevel, format, args...)
 	}
 }
 
@@ -168,58 +231,166 @@ func (l *Logger) SetLogLevel(level LogLevel) {
 	l.logLevel = level
 }
 
+// SetExitFunc changes the function Fatalf calls to terminate the program
+func (l *Logger) SetExitFunc(exit func(code int)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.exit = exit
+}
+
 // WithField returns a copy of the logger with a custom field set, which will be
 // included in all subsequent logs
 func (l *Logger) WithField(key string, value interface{}) *Logger {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	fields := make(Entry, len(l.fields)+1)
-	fields[key] = value
+	f := newField(key, value)
+	l.reportMarshalError(&f)
 
-	for k, v := range l.fields {
-		fields[k] = v
-	}
+	clone := l.clone()
+	clone.fields = l.fields.with(f)
+	return clone
+}
+
+// WithFields returns a copy of the logger with all passed in custom fields set,
+// which will be included in all subsequent logs
+func (l *Logger) WithFields(fields Entry) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
-	clone := NewLogger(l.out)
-	clone.fields = fields
+	clone := l.clone()
+	clone.fields = l.fields.withEntry(fields)
+	l.reportMarshalErrors(clone.fields, fields)
 	return clone
 }
 
-// log builds the final log data by concatenating the log template array data with
-// the values for log level, timestamp and log message
-func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
-	entry := l.generateLogEntry(level, format, args...)
+// clone returns a copy of the logger carrying over all of its settings. The
+// clone shares the output mutex with the original logger, so that writes of
+// both loggers to the same output stay atomic. The caller must hold l.mu.
+func (l *Logger) clone() *Logger {
+	return &Logger{
+		FieldKeyMsg:   l.FieldKeyMsg,
+		FieldKeyLevel: l.FieldKeyLevel,
+		FieldKeyTime:  l.FieldKeyTime,
+		fields:        l.fields,
+		logLevel:      l.logLevel,
+		outMu:         l.outMu,
+		out:           l.out,
+		exit:          l.exit,
+
+		reportCaller:   l.reportCaller,
+		reportFunction: l.reportFunction,
+		callerSkip:     l.callerSkip,
+
+		stacktraceLevel: l.stacktraceLevel,
+
+		clock:         l.clock,
+		timePrecision: l.timePrecision,
+		timeLocation:  l.timeLocation,
+		timeEncoder:   l.timeEncoder,
+		encoder:       l.encoder,
+
+		hooks:        l.hooks,
+		errorHandler: l.errorHandler,
+		stats:        l.stats,
+
+		contextExtractors: l.contextExtractors,
+	}
+}
 
-	// wrap Write() method call in mutex to guarantee atomic writes
-	l.outMu.Lock()
-	defer l.outMu.Unlock()
+// log builds the final log entry from the logger fields, the fields extracted
+// from ctx and the values for log level, timestamp and log message and writes
+// it to the output destination. It must be called directly by the exported
+// logging methods, as the caller information is looked up by a fixed stack
+// depth.
+func (l *Logger) log(ctx context.Context, level LogLevel, format string, args ...interface{}) {
+	var pc uintptr
+	if l.reportCaller || l.reportFunction || l.logsStacktrace(level) {
+		pc = callerPC(callerDepth + l.callerSkip)
+	}
+
+	l.write(ctx, level, pc, format, args...)
+}
+
+// write logs a message called from the program counter pc, which may be zero if
+// neither the caller information nor stack traces are reported
+func (l *Logger) write(ctx context.Context, level LogLevel, pc uintptr, format string, args ...interface{}) {
+	e := l.newEntry(ctx, level, format, args...)
+	defer putEntry(e)
 
-	l.out.Write(append(entry, '\n'))
+	l.writeEntry(e, pc)
 }
 
-// generateLogEntry generates a log entry by gathering all field data and marshal
-// everthing to json format
-func (l *Logger) generateLogEntry(level LogLevel, format string, args ...interface{}) []byte {
-	var msg string
+// newEntry returns an entry from the pool with the timestamp, level and
+// formatted message set. It must be returned with putEntry.
+func (l *Logger) newEntry(ctx context.Context, level LogLevel, format string, args ...interface{}) *EntryView {
+	e := getEntry(l)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	e.ctx = ctx
+	e.time = l.now()
+	e.level = level
 	if len(args) > 0 {
-		msg = fmt.Sprintf(format, args...)
+		fmt.Fprintf(&e.msg, format, args...)
 	} else {
-		msg = format
+		e.msg.b = append(e.msg.b, format...)
+	}
+	return e
+}
+
+// writeEntry adds the context fields, caller information and stack trace to
+// the entry, fires the hooks and writes it to the output destination
+func (l *Logger) writeEntry(e *EntryView, pc uintptr) {
+	l.addContextFields(e.ctx, e)
+	if pc != 0 && (l.reportCaller || l.reportFunction) {
+		l.addCaller(e, pc)
+	}
+	if l.logsStacktrace(e.level) {
+		l.addStacktrace(e, pc)
+	}
+	if !l.fireHooks(e) {
+		return
 	}
 
-	data := make(Entry, len(l.fields)+3)
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	data[l.FieldKeyTime] = Now()
-	data[l.FieldKeyLevel] = level.String()
-	data[l.FieldKeyMsg] = msg
+	buf.b = l.generateLogEntry(buf.b, e)
+	buf.b = append(buf.b, '\n')
 
-	for k, v := range l.fields {
-		data[k] = v
+	// wrap Write() method call in mutex to guarantee atomic writes
+	l.outMu.Lock()
+	var err error
+	if lw, ok := l.out.(LevelWriter); ok {
+		_, err = lw.WriteLevel(e.level, buf.b)
+	} else {
+		_, err = l.out.Write(buf.b)
 	}
+	l.outMu.Unlock()
 
-	// Error is ignored intentionally, as no errors are expected because the
-	// data type to be marshaled will never change.
-	entry, _ := easyjson.Marshal(data)
-	return entry
+	if err != nil {
+		l.reportWriteError(err)
+	}
+}
+
+// flush flushes data buffered by the output destination, if it supports it
+func (l *Logger) flush() {
+	l.outMu.Lock()
+	defer l.outMu.Unlock()
+
+	flushWriter(l.out)
+}
+
+// flushWriter flushes data buffered by w, if it supports it
+func flushWriter(w io.Writer) error {
+	switch w := w.(type) {
+	case interface{ Sync() error }:
+		return w.Sync()
+	case interface{ Flush() error }:
+		return w.Flush()
+	}
+	return nil
 }
@@ -1,12 +1,14 @@
 package jlo_test
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -232,8 +234,14 @@ func Test_Logger_Fatalf(t *testing.T) {
 			l := jlo.NewLogger(buf)
 			l.SetLogLevel(jlo.FatalLevel)
 
+			exitCode := -1
+			l.SetExitFunc(func(code int) {
+				exitCode = code
+			})
+
 			l.Fatalf(test.String, test.Args...)
 
+			assert.Equal(t, 1, exitCode)
 			assert.JSONEq(t, fmt.Sprintf(`{
 				"@message":   "%s",
 				"@level":     "fatal",
@@ -243,6 +251,80 @@ func Test_Logger_Fatalf(t *testing.T) {
 	}
 }
 
+type syncBuffer struct {
+	bytes.Buffer
+	synced int
+}
+
+func (b *syncBuffer) Sync() error {
+	b.synced++
+	return nil
+}
+
+func Test_Logger_Fatalf_SyncsOutputBeforeExit(t *testing.T) {
+	buf := &syncBuffer{}
+	l := jlo.NewLogger(buf)
+
+	var syncedOnExit int
+	l.SetExitFunc(func(code int) {
+		syncedOnExit = buf.synced
+	})
+
+	l.Fatalf("I'm real")
+	assert.Equal(t, 1, syncedOnExit)
+}
+
+func Test_Logger_Fatalf_FlushesBufferedOutput(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := bufio.NewWriter(buf)
+	l := jlo.NewLogger(w)
+	l.SetExitFunc(func(code int) {})
+
+	l.Fatalf("I'm real")
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":   "I'm real",
+		"@level":     "fatal",
+		"@timestamp": "%s"
+	}`, testTime), buf.String())
+}
+
+func Test_Logger_Panicf(t *testing.T) {
+
+	tests := map[string]struct {
+		String  string
+		Args    []interface{}
+		Message string
+	}{
+		"simple": {
+			String:  "I'm real",
+			Message: "I'm real",
+		},
+		"with format args": {
+			String:  "string: %s int: %d float: %.2f bool: %t",
+			Args:    []interface{}{"I'm real", 5, 0.1, true},
+			Message: "string: I'm real int: 5 float: 0.10 bool: true",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetLogLevel(jlo.PanicLevel)
+
+			assert.PanicsWithValue(t, test.Message, func() {
+				l.Panicf(test.String, test.Args...)
+			})
+
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"@message":   "%s",
+				"@level":     "panic",
+				"@timestamp": "%s"
+			}`, test.Message, testTime), buf.String())
+		})
+	}
+}
+
 func Test_Logger_Infof_EnsureNewlineDelimitedJSON(t *testing.T) {
 	buf := bytes.NewBuffer(nil)
 	l := jlo.NewLogger(buf)
@@ -363,6 +445,126 @@ func Test_Logger_WithField_EnsureNoChangeWhenIgnoringReturnValue(t *testing.T) {
 	}`, testTime), buf.String())
 }
 
+func Test_Logger_Infof_ZeroAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates")
+	}
+
+	l := newPresetFieldsLogger()
+
+	tests := map[string]func(){
+		"without args": func() {
+			l.Infof(testStringShort)
+		},
+		"with args": func() {
+			l.Infof(testStringShort+"%s %d", "I'm real", 42)
+		},
+		"below log level": func() {
+			l.Debugf(testStringShort+"%s %d", "I'm real", 42)
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Zero(t, testing.AllocsPerRun(100, test))
+		})
+	}
+}
+
+func Test_Logger_WithField_PreservesSettings(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetLogLevel(jlo.DebugLevel)
+	l.FieldKeyLevel = "lvl"
+	l.FieldKeyMsg = "msg"
+	l.FieldKeyTime = "time"
+
+	exitCode := -1
+	l.SetExitFunc(func(code int) {
+		exitCode = code
+	})
+
+	child := l.WithField("@request_id", "e44c2a9")
+	child.Debugf("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"lvl": "debug",
+		"msg": "I'm real",
+		"time": "%s",
+		"@request_id": "e44c2a9"
+	}`, testTime), buf.String())
+
+	buf.Reset()
+	child.Fatalf("I'm real")
+	assert.Equal(t, 1, exitCode)
+}
+
+func Test_Logger_WithField_ChildLogLevelIsIndependent(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetLogLevel(jlo.DebugLevel)
+
+	child := l.WithField("@request_id", "e44c2a9")
+	child.SetLogLevel(jlo.ErrorLevel)
+
+	child.Infof("should not log")
+	assert.Empty(t, buf.String())
+
+	l.Debugf("should log")
+	assert.NotEmpty(t, buf.String())
+}
+
+func Test_Logger_WithFields(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.WithField("@request_id", "e44c2a9").
+		WithField("@revision", 5).
+		WithFields(jlo.Entry{
+			"@version":  2.1,
+			"@revision": 6,
+			"@a":        true,
+		}).
+		Infof("I'm real")
+
+	assert.Equal(t, fmt.Sprintf(
+		`{"@a":true,"@level":"info","@message":"I'm real","@request_id":"e44c2a9","@revision":6,"@timestamp":"%s","@version":2.1}`+"\n",
+		testTime,
+	), buf.String())
+
+	// check that original logger is unaffected
+	buf.Reset()
+	l.Infof("I'm real")
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":    "I'm real",
+		"@level":      "info",
+		"@timestamp":  "%s"
+	}`, testTime), buf.String())
+}
+
+func Test_Logger_WithField_ConcurrentWritesAreAtomic(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := l.WithField("@worker", i)
+			for j := 0; j < 100; j++ {
+				child.Infof(testStringLong)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	dec := json.NewDecoder(buf)
+	for i := 0; i < 1000; i++ {
+		var msg map[string]interface{}
+		require.NoError(t, dec.Decode(&msg))
+	}
+}
+
 func Test_Logger_SetLogLevel(t *testing.T) {
 
 	tests := map[string]struct {
@@ -554,6 +756,23 @@ func Benchmark_Logger_ShortString_WithFieldAndArgs(b *testing.B) {
 	benchmarkLoggerWithField(b, testStringShort+"%s", "I'm real")
 }
 
+func Benchmark_Logger_ShortString_WithPresetFields(b *testing.B) {
+	benchmarkLoggerWithPresetFields(b, testStringShort)
+}
+
+func Benchmark_Logger_ShortString_WithPresetFieldsAndArgs(b *testing.B) {
+	benchmarkLoggerWithPresetFields(b, testStringShort+"%s %d", "I'm real", 42)
+}
+
+func Benchmark_Logger_ShortString_WithCaller(b *testing.B) {
+	l := jlo.NewLogger(ioutil.Discard)
+	l.SetReportCaller(true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infof(testStringShort)
+	}
+}
+
 func Benchmark_Logger_VeryLongString(b *testing.B) {
 	benchmarkLogger(b, testStringLong)
 }
@@ -570,8 +789,28 @@ func Benchmark_Logger_VeryLongString_WithFieldsAndArgs(b *testing.B) {
 	benchmarkLoggerWithField(b, testStringLong+"%s", "I'm real")
 }
 
+func Benchmark_Logger_VeryLongString_WithPresetFields(b *testing.B) {
+	benchmarkLoggerWithPresetFields(b, testStringLong)
+}
+
+func Benchmark_Logger_VeryLongString_WithPresetFieldsAndArgs(b *testing.B) {
+	benchmarkLoggerWithPresetFields(b, testStringLong+"%s %d", "I'm real", 42)
+}
+
+func Benchmark_Logger_Parallel_WithPresetFieldsAndArgs(b *testing.B) {
+	l := newPresetFieldsLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Infof(testStringShort+"%s %d", "I'm real", 42)
+		}
+	})
+}
+
 func benchmarkLogger(b *testing.B, format string, args ...interface{}) {
 	l := jlo.NewLogger(ioutil.Discard)
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		l.Infof(format, args...)
 	}
@@ -579,7 +818,26 @@ func benchmarkLogger(b *testing.B, format string, args ...interface{}) {
 
 func benchmarkLoggerWithField(b *testing.B, format string, args ...interface{}) {
 	l := jlo.NewLogger(ioutil.Discard)
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		l.WithField("I'm", "real").Infof(format, args...)
 	}
 }
+
+func benchmarkLoggerWithPresetFields(b *testing.B, format string, args ...interface{}) {
+	l := newPresetFieldsLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Infof(format, args...)
+	}
+}
+
+func newPresetFieldsLogger() *jlo.Logger {
+	return jlo.NewLogger(ioutil.Discard).
+		WithField("@request_id", "e44c2a9").
+		WithField("@version", 2.1).
+		WithField("@revision", 6).
+		WithField("@success", true).
+		WithField("@started", time.Date(2018, 8, 2, 21, 48, 56, 0, time.UTC))
+}
@@ -0,0 +1,16 @@
+module github.com/dcmn-com/jlo/jlohttp
+
+go 1.23
+
+require (
+	github.com/dcmn-com/jlo v0.0.0-20261017030950-d34765d15d0d
+	github.com/stretchr/testify v1.4.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/snappy v1.0.0 // indirect
+	github.com/mailru/easyjson v0.7.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
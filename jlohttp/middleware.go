@@ -0,0 +1,236 @@
+// Package jlohttp logs HTTP requests served by net/http handlers with jlo
+package jlohttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+)
+
+const (
+	// DefaultRequestIDHeader is the request id header if none is set
+	DefaultRequestIDHeader = "X-Request-Id"
+
+	// FieldKeyRequestID is the request id log field name
+	FieldKeyRequestID = "@request_id"
+	// FieldKeyMethod is the request method log field name
+	FieldKeyMethod = "method"
+	// FieldKeyPath is the request path log field name
+	FieldKeyPath = "path"
+	// FieldKeyRoute is the route pattern log field name
+	FieldKeyRoute = "route"
+	// FieldKeyStatus is the response status log field name
+	FieldKeyStatus = "status"
+	// FieldKeyBytes is the response size log field name
+	FieldKeyBytes = "bytes"
+	// FieldKeyDuration is the request duration in milliseconds log field name
+	FieldKeyDuration = "duration_ms"
+	// FieldKeyRemoteAddr is the client address log field name
+	FieldKeyRemoteAddr = "remote_addr"
+	// FieldKeyUserAgent is the user agent log field name
+	FieldKeyUserAgent = "user_agent"
+)
+
+// Options configures the request logging middleware
+type Options struct {
+	// RequestIDHeader is the header the request id is read from. Requests
+	// without it get a generated id. The id is returned in the same response
+	// header. It defaults to DefaultRequestIDHeader.
+	RequestIDHeader string
+	// GenerateRequestID generates request ids. It defaults to 16 random bytes
+	// in hex.
+	GenerateRequestID func() string
+	// SkipPaths are request paths which are not logged, e.g. health checks
+	SkipPaths []string
+	// Level returns the log level of a request by its response status. It
+	// defaults to ErrorLevel for server errors, WarningLevel for client
+	// errors and InfoLevel otherwise.
+	Level func(status int) jlo.LogLevel
+}
+
+// Middleware returns a middleware which logs one entry per request, with
+// status 500 if the handler panics. The handlers get a child logger with the
+// request id set, which is retrieved with jlo.FromContext(r.Context()).
+func Middleware(l *jlo.Logger, opts Options) func(http.Handler) http.Handler {
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if opts.GenerateRequestID == nil {
+		opts.GenerateRequestID = generateRequestID
+	}
+	if opts.Level == nil {
+		opts.Level = statusLevel
+	}
+
+	skip := make(map[string]bool, len(opts.SkipPaths))
+	for _, path := range opts.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(opts.RequestIDHeader)
+			if id == "" {
+				id = opts.GenerateRequestID()
+			}
+			w.Header().Set(opts.RequestIDHeader, id)
+
+			rl := l.WithField(FieldKeyRequestID, id)
+			r = r.WithContext(jlo.NewContext(r.Context(), rl))
+
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				p := recover()
+				if p != nil {
+					// net/http recovers the panic and aborts the response
+					rw.status = http.StatusInternalServerError
+				}
+				if !skip[r.URL.Path] {
+					logRequest(rl, r, rw, start, opts.Level)
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// logRequest logs the request with the recorded response
+func logRequest(l *jlo.Logger, r *http.Request, rw *responseWriter, start time.Time, level func(status int) jlo.LogLevel) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	fields := jlo.Entry{
+		FieldKeyMethod:     r.Method,
+		FieldKeyPath:       r.URL.Path,
+		FieldKeyStatus:     status,
+		FieldKeyBytes:      rw.bytes,
+		FieldKeyDuration:   float64(time.Since(start)) / float64(time.Millisecond),
+		FieldKeyRemoteAddr: r.RemoteAddr,
+		FieldKeyUserAgent:  r.UserAgent(),
+	}
+	// the pattern is set by http.ServeMux on the request passed to it
+	if r.Pattern != "" {
+		fields[FieldKeyRoute] = r.Pattern
+	}
+
+	logLevel(l.WithFields(fields), r, level(status), "%s %s %d", r.Method, r.URL.Path, status)
+}
+
+// logLevel logs the message with the request context on the given level
+func logLevel(l *jlo.Logger, r *http.Request, level jlo.LogLevel, format string, args ...interface{}) {
+	ctx := r.Context()
+	switch level {
+	case jlo.DebugLevel:
+		l.DebugContext(ctx, format, args...)
+	case jlo.WarningLevel:
+		l.WarnContext(ctx, format, args...)
+	case jlo.ErrorLevel, jlo.FatalLevel, jlo.PanicLevel:
+		// requests must not terminate the program
+		l.ErrorContext(ctx, format, args...)
+	default:
+		l.InfoContext(ctx, format, args...)
+	}
+}
+
+// statusLevel returns the default log level of a response status
+func statusLevel(status int) jlo.LogLevel {
+	switch {
+	case status >= 500:
+		return jlo.ErrorLevel
+	case status >= 400:
+		return jlo.WarningLevel
+	default:
+		return jlo.InfoLevel
+	}
+}
+
+// generateRequestID returns 16 random bytes in hex
+func generateRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records the status and size of the response
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *responseWriter) WriteHeader(status int) {
+	// informational responses are followed by the final status
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// ReadFrom implements io.ReaderFrom, so that the wrapped response writer can
+// use sendfile
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		// hide ReadFrom from io.Copy to avoid recursion
+		return io.Copy(struct{ io.Writer }{w}, r)
+	}
+	n, err := rf.ReadFrom(r)
+	w.bytes += int(n)
+	return n, err
+}
+
+// Hijack implements http.Hijacker. Hijacked connections, e.g. websockets, are
+// logged with status 101 unless a status was written before.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped response writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,292 @@
+package jlohttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+	"github.com/dcmn-com/jlo/jlohttp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2018, 8, 2, 21, 48, 56, 856339554, time.UTC)
+
+// newLogger creates a logger with its clock frozen at testTime
+func newLogger(out io.Writer, opts ...jlo.Option) *jlo.Logger {
+	l := jlo.NewLogger(out, opts...)
+	l.SetClock(jlo.NewFrozenClock(testTime))
+	return l
+}
+
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		jlo.FromContext(r.Context()).Infof("loading user")
+		w.Write([]byte("I'm real"))
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "I'm broken", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	return mux
+}
+
+func Test_Middleware(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := newLogger(buf)
+	h := jlohttp.Middleware(l, jlohttp.Options{})(newMux())
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.Header.Set("X-Request-Id", "e44c2a9")
+	r.Header.Set("User-Agent", "curl/8.0")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, "e44c2a9", w.Header().Get("X-Request-Id"))
+
+	entries := decodeEntries(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, map[string]interface{}{
+		"@level":      "info",
+		"@message":    "loading user",
+		"@request_id": "e44c2a9",
+		"@timestamp":  "2018-08-02T21:48:56.856339554Z",
+	}, entries[0])
+
+	assert.GreaterOrEqual(t, entries[1]["duration_ms"], 0.0)
+	delete(entries[1], "duration_ms")
+	assert.Equal(t, map[string]interface{}{
+		"@level":      "info",
+		"@message":    "GET /users/42 200",
+		"@request_id": "e44c2a9",
+		"@timestamp":  "2018-08-02T21:48:56.856339554Z",
+		"bytes":       8.0,
+		"method":      "GET",
+		"path":        "/users/42",
+		"remote_addr": "192.0.2.1:1234",
+		"route":       "GET /users/{id}",
+		"status":      200.0,
+		"user_agent":  "curl/8.0",
+	}, entries[1])
+}
+
+func Test_Middleware_Levels(t *testing.T) {
+
+	tests := map[string]struct {
+		Method  string
+		Path    string
+		Options jlohttp.Options
+		Level   string
+		Status  float64
+	}{
+		"client error": {
+			Method: http.MethodPost,
+			Path:   "/users",
+			Level:  "warning",
+			Status: 400,
+		},
+		"server error": {
+			Method: http.MethodGet,
+			Path:   "/fail",
+			Level:  "error",
+			Status: 503,
+		},
+		"not found": {
+			Method: http.MethodGet,
+			Path:   "/missing",
+			Level:  "warning",
+			Status: 404,
+		},
+		"custom level": {
+			Method:  http.MethodGet,
+			Path:    "/fail",
+			Options: jlohttp.Options{Level: func(status int) jlo.LogLevel { return jlo.InfoLevel }},
+			Level:   "info",
+			Status:  503,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			h := jlohttp.Middleware(newLogger(buf), test.Options)(newMux())
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(test.Method, test.Path, nil))
+
+			entries := decodeEntries(t, buf)
+			require.Len(t, entries, 1)
+			assert.Equal(t, test.Level, entries[0]["@level"])
+			assert.Equal(t, test.Status, entries[0]["status"])
+		})
+	}
+}
+
+func Test_Middleware_GeneratesRequestID(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := jlohttp.Middleware(newLogger(buf), jlohttp.Options{
+		RequestIDHeader:   "X-Correlation-Id",
+		GenerateRequestID: func() string { return "generated" },
+	})(newMux())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	assert.Equal(t, "generated", w.Header().Get("X-Correlation-Id"))
+	for _, entry := range decodeEntries(t, buf) {
+		assert.Equal(t, "generated", entry["@request_id"])
+	}
+}
+
+func Test_Middleware_DefaultRequestID(t *testing.T) {
+	h := jlohttp.Middleware(newLogger(bytes.NewBuffer(nil)), jlohttp.Options{})(newMux())
+
+	w1 := httptest.NewRecorder()
+	h.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	w2 := httptest.NewRecorder()
+	h.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Len(t, w1.Header().Get("X-Request-Id"), 32)
+	assert.NotEqual(t, w1.Header().Get("X-Request-Id"), w2.Header().Get("X-Request-Id"))
+}
+
+func Test_Middleware_SkipPaths(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := jlohttp.Middleware(newLogger(buf), jlohttp.Options{SkipPaths: []string{"/healthz"}})(newMux())
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, buf.String())
+}
+
+func Test_Middleware_Panic(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := jlohttp.Middleware(newLogger(buf), jlohttp.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("I'm "))
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	})
+
+	entries := decodeEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "error", entries[0]["@level"])
+	assert.Equal(t, "GET /users/42 500", entries[0]["@message"])
+	assert.Equal(t, 500.0, entries[0]["status"])
+	assert.Equal(t, 4.0, entries[0]["bytes"])
+}
+
+func Test_Middleware_Flush(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	h := jlohttp.Middleware(newLogger(buf), jlohttp.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, http.NewResponseController(w).Flush())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.True(t, w.Flushed)
+	assert.Equal(t, 200.0, decodeEntries(t, buf)[0]["status"])
+}
+
+func Test_Middleware_Hijack(t *testing.T) {
+	buf := &lockedBuffer{}
+	s := httptest.NewServer(jlohttp.Middleware(newLogger(buf), jlohttp.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+	})))
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: jlo\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+	require.NoError(t, err)
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n", status)
+
+	require.Eventually(t, func() bool { return buf.String() != "" }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 101.0, decodeEntries(t, bytes.NewBufferString(buf.String()))[0]["status"])
+}
+
+func Test_Middleware_Hijack_NotSupported(t *testing.T) {
+	h := jlohttp.Middleware(newLogger(bytes.NewBuffer(nil)), jlohttp.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := w.(http.Hijacker).Hijack()
+		assert.Equal(t, http.ErrNotSupported, err)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+}
+
+func Test_Middleware_ReadFrom(t *testing.T) {
+	buf := &lockedBuffer{}
+	s := httptest.NewServer(jlohttp.Middleware(newLogger(buf), jlohttp.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(io.ReaderFrom)
+		assert.True(t, ok)
+		io.Copy(w, strings.NewReader("I'm real"))
+	})))
+	defer s.Close()
+
+	res, err := http.Get(s.URL + "/file")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "I'm real", string(body))
+
+	require.Eventually(t, func() bool { return buf.String() != "" }, time.Second, 10*time.Millisecond)
+	entry := decodeEntries(t, bytes.NewBufferString(buf.String()))[0]
+	assert.Equal(t, 200.0, entry["status"])
+	assert.Equal(t, 8.0, entry["bytes"])
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,144 @@
+// Package jlootel correlates jlo log entries with OpenTelemetry traces
+package jlootel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dcmn-com/jlo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// FieldKeyTraceID is the trace id log field name
+	FieldKeyTraceID = "trace_id"
+	// FieldKeySpanID is the span id log field name
+	FieldKeySpanID = "span_id"
+	// FieldKeyTraceFlags is the trace flags log field name
+	FieldKeyTraceFlags = "trace_flags"
+
+	// SpanEventName is the name of span events recorded for log entries
+	SpanEventName = "log"
+	// AttributeKeySeverity is the log level attribute key of span events
+	AttributeKeySeverity = "log.severity"
+	// AttributeKeyMessage is the log message attribute key of span events
+	AttributeKeyMessage = "log.message"
+)
+
+// Config configures the trace correlation of a logger
+type Config struct {
+	// SpanEvents enables recording log entries as events of the active span
+	SpanEvents bool
+	// SpanEventLevels are the log levels recorded as span events. They default
+	// to all levels.
+	SpanEventLevels []jlo.LogLevel
+}
+
+// WithTracing returns an option which adds the trace id, span id and trace
+// flags of the span in the context to entries logged with one of the context
+// methods like InfoContext and optionally records them as span events
+func WithTracing(config Config) jlo.Option {
+	return func(l *jlo.Logger) {
+		jlo.WithContextExtractors(ExtractTraceFields)(l)
+		if config.SpanEvents {
+			jlo.WithHooks(SpanEventHook{LogLevels: config.SpanEventLevels})(l)
+		}
+	}
+}
+
+// ExtractTraceFields is a jlo.ContextExtractor returning the trace id, span id
+// and trace flags of the span in the context, if it is valid
+func ExtractTraceFields(ctx context.Context) jlo.Entry {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return jlo.Entry{
+		FieldKeyTraceID:    sc.TraceID().String(),
+		FieldKeySpanID:     sc.SpanID().String(),
+		FieldKeyTraceFlags: sc.TraceFlags().String(),
+	}
+}
+
+// SpanEventHook records log entries as events of the recording span in the
+// context the entries are logged with. The custom fields become event
+// attributes, except for the trace correlation fields.
+type SpanEventHook struct {
+	// LogLevels are the log levels the hook fires for. They default to all
+	// levels.
+	LogLevels []jlo.LogLevel
+}
+
+// Levels implements jlo.Hook
+func (h SpanEventHook) Levels() []jlo.LogLevel {
+	if len(h.LogLevels) == 0 {
+		return jlo.AllLevels()
+	}
+	return h.LogLevels
+}
+
+// Fire implements jlo.Hook
+func (h SpanEventHook) Fire(e *jlo.EntryView) error {
+	span := trace.SpanFromContext(e.Context())
+	if !span.IsRecording() {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String(AttributeKeySeverity, e.Level().String()),
+		attribute.String(AttributeKeyMessage, e.Message()),
+	}
+	e.Range(func(key string, value interface{}) bool {
+		switch key {
+		case FieldKeyTraceID, FieldKeySpanID, FieldKeyTraceFlags:
+		default:
+			attrs = append(attrs, attributeValue(key, value))
+		}
+		return true
+	})
+
+	span.AddEvent(SpanEventName, trace.WithTimestamp(e.Time()), trace.WithAttributes(attrs...))
+	return nil
+}
+
+// attributeValue converts a field value to an attribute, using the json
+// representation for values without a matching attribute type
+func attributeValue(key string, value interface{}) (kv attribute.KeyValue) {
+	// methods of typed nil errors and stringers may panic
+	defer func() {
+		if recover() != nil {
+			kv = attribute.String(key, "null")
+		}
+	}()
+
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case []string:
+		return attribute.StringSlice(key, v)
+	case error:
+		return attribute.String(key, v.Error())
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	}
+
+	if b, err := json.Marshal(value); err == nil {
+		return attribute.String(key, string(b))
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
@@ -0,0 +1,151 @@
+package jlootel_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+	"github.com/dcmn-com/jlo/jlootel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2018, 8, 2, 21, 48, 56, 856339554, time.UTC)
+
+// newLogger creates a logger with its clock frozen at testTime
+func newLogger(out io.Writer, opts ...jlo.Option) *jlo.Logger {
+	l := jlo.NewLogger(out, opts...)
+	l.SetClock(jlo.NewFrozenClock(testTime))
+	return l
+}
+
+func newTracer() (trace.Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return tp.Tracer("jlootel_test"), exporter
+}
+
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func Test_ExtractTraceFields(t *testing.T) {
+	tracer, _ := newTracer()
+	ctx, span := tracer.Start(context.Background(), "request")
+	defer span.End()
+
+	buf := bytes.NewBuffer(nil)
+	l := newLogger(buf, jlootel.WithTracing(jlootel.Config{}))
+	l.InfoContext(ctx, "I'm real")
+
+	sc := span.SpanContext()
+	assert.Equal(t, map[string]interface{}{
+		"@level":      "info",
+		"@message":    "I'm real",
+		"@timestamp":  "2018-08-02T21:48:56.856339554Z",
+		"trace_id":    sc.TraceID().String(),
+		"span_id":     sc.SpanID().String(),
+		"trace_flags": "01",
+	}, decodeEntry(t, buf))
+}
+
+func Test_ExtractTraceFields_NoSpan(t *testing.T) {
+	assert.Nil(t, jlootel.ExtractTraceFields(context.Background()))
+
+	buf := bytes.NewBuffer(nil)
+	l := newLogger(buf, jlootel.WithTracing(jlootel.Config{}))
+	l.InfoContext(context.Background(), "I'm real")
+	assert.NotContains(t, decodeEntry(t, buf), "trace_id")
+}
+
+// nilError panics when its Error method is called on a nil pointer
+type nilError struct {
+	msg string
+}
+
+func (e *nilError) Error() string {
+	return e.msg
+}
+
+func Test_SpanEventHook(t *testing.T) {
+
+	tests := map[string]struct {
+		Config jlootel.Config
+		Log    func(l *jlo.Logger, ctx context.Context)
+		Events [][]attribute.KeyValue
+	}{
+		"disabled": {
+			Log: func(l *jlo.Logger, ctx context.Context) { l.InfoContext(ctx, "I'm real") },
+		},
+		"enabled": {
+			Config: jlootel.Config{SpanEvents: true},
+			Log: func(l *jlo.Logger, ctx context.Context) {
+				l.WithFields(jlo.Entry{
+					"@request_id": "e44c2a9",
+					"count":       42,
+					"error":       errors.New("I'm broken"),
+					"map":         map[string]int{"a": 1},
+					"nil":         (*nilError)(nil),
+				}).WarnContext(ctx, "I'm %s", "real")
+			},
+			Events: [][]attribute.KeyValue{{
+				attribute.String("log.severity", "warning"),
+				attribute.String("log.message", "I'm real"),
+				attribute.String("@request_id", "e44c2a9"),
+				attribute.Int("count", 42),
+				attribute.String("error", "I'm broken"),
+				attribute.String("map", `{"a":1}`),
+				attribute.String("nil", "null"),
+			}},
+		},
+		"levels": {
+			Config: jlootel.Config{SpanEvents: true, SpanEventLevels: []jlo.LogLevel{jlo.ErrorLevel}},
+			Log: func(l *jlo.Logger, ctx context.Context) {
+				l.InfoContext(ctx, "first")
+				l.ErrorContext(ctx, "second")
+			},
+			Events: [][]attribute.KeyValue{{
+				attribute.String("log.severity", "error"),
+				attribute.String("log.message", "second"),
+			}},
+		},
+		"without context": {
+			Config: jlootel.Config{SpanEvents: true},
+			Log:    func(l *jlo.Logger, ctx context.Context) { l.Infof("I'm real") },
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracer, exporter := newTracer()
+			ctx, span := tracer.Start(context.Background(), "request")
+
+			l := newLogger(bytes.NewBuffer(nil), jlootel.WithTracing(test.Config))
+			test.Log(l, ctx)
+			span.End()
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+
+			var events [][]attribute.KeyValue
+			for _, event := range spans[0].Events {
+				assert.Equal(t, "log", event.Name)
+				assert.True(t, testTime.Equal(event.Time))
+				events = append(events, event.Attributes)
+			}
+			assert.Equal(t, test.Events, events)
+		})
+	}
+}
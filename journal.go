@@ -0,0 +1,190 @@
+package jlo
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultJournalSocket is the path of the journald native protocol socket
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// maxJournalFieldName is the maximum length of journal field names
+const maxJournalFieldName = 64
+
+// JournalEncoder encodes log entries in the journald native protocol format.
+// The message is written as MESSAGE, the level as PRIORITY with its syslog
+// severity and custom fields with their keys converted to journal field names,
+// e.g. "@request_id" becomes REQUEST_ID. The timestamp is omitted, as journald
+// records the time of reception.
+type JournalEncoder struct {
+	// Identifier is written as SYSLOG_IDENTIFIER, if set
+	Identifier string
+}
+
+// AppendEntry implements Encoder
+func (enc JournalEncoder) AppendEntry(dst []byte, e *EntryView) []byte {
+	var scratch [64]byte
+
+	dst = appendJournalField(dst, "PRIORITY", strconv.AppendInt(scratch[:0], int64(syslogSeverity(e.level)), 10))
+	if enc.Identifier != "" {
+		dst = append(dst, '\n')
+		dst = appendJournalField(dst, "SYSLOG_IDENTIFIER", []byte(enc.Identifier))
+	}
+	if _, ok := e.Field(e.logger.FieldKeyMsg); !ok {
+		dst = append(dst, '\n')
+		dst = appendJournalField(dst, "MESSAGE", e.msg.b)
+	}
+
+	var name [maxJournalFieldName]byte
+	fs := e.customFields()
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		if n := journalFieldName(name[:0], f.key); len(n) > 0 {
+			dst = append(dst, '\n')
+			dst = appendJournalField(dst, string(n), appendJournalValue(scratch[:0], f.value))
+		}
+		fs.next()
+	}
+
+	return dst
+}
+
+// appendJournalField appends the field without a trailing newline. Values
+// containing newlines are written in the binary safe format prefixed by their
+// length.
+func appendJournalField(dst []byte, name string, value []byte) []byte {
+	dst = append(dst, name...)
+
+	for _, c := range value {
+		if c == '\n' {
+			dst = append(dst, '\n')
+			dst = binary.LittleEndian.AppendUint64(dst, uint64(len(value)))
+			return append(dst, value...)
+		}
+	}
+
+	dst = append(dst, '=')
+	return append(dst, value...)
+}
+
+// journalFieldName appends the journal field name for key, which consists of
+// upper case letters, digits and underscores and starts with a letter. Other
+// characters are replaced by underscores and leading non-letters are removed.
+func journalFieldName(dst []byte, key string) []byte {
+	for i := 0; i < len(key) && len(dst) < maxJournalFieldName; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			dst = append(dst, c-'a'+'A')
+		case c >= 'A' && c <= 'Z':
+			dst = append(dst, c)
+		case len(dst) == 0:
+			// field names must start with a letter
+		case c >= '0' && c <= '9':
+			dst = append(dst, c)
+		default:
+			dst = append(dst, '_')
+		}
+	}
+	return dst
+}
+
+// appendJournalValue appends the value as plain text, using its json
+// representation for anything but strings, errors and times
+func appendJournalValue(dst []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return append(dst, v...)
+	case error:
+		if msg, ok := errorMessage(v); ok {
+			return append(dst, msg...)
+		}
+		// typed nil errors are written as null
+	case time.Time:
+		return v.AppendFormat(dst, time.RFC3339Nano)
+	}
+
+	js, err := appendJSONValue(dst, v)
+	if err != nil {
+		return append(dst, badValue(err)...)
+	}
+	return js
+}
+
+// JournalConfig configures a JournalWriter
+type JournalConfig struct {
+	// Socket is the path of the journald socket. It defaults to
+	// DefaultJournalSocket.
+	Socket string
+}
+
+// JournalWriter sends log entries encoded by JournalEncoder to journald.
+// Entries exceeding the maximum datagram size are passed as a file
+// descriptor of an unlinked temporary file.
+type JournalWriter struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournalWriter creates a new journal writer connected to the journald
+// socket
+func NewJournalWriter(config JournalConfig) (*JournalWriter, error) {
+	if config.Socket == "" {
+		config.Socket = DefaultJournalSocket
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: config.Socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournalWriter{conn: conn}, nil
+}
+
+// WithJournal returns an option which makes the logger write to journald
+// using a JournalEncoder. The logger keeps the output passed to NewLogger if
+// journald is not available.
+func WithJournal(config JournalConfig, enc JournalEncoder) Option {
+	return func(l *Logger) {
+		w, err := NewJournalWriter(config)
+		if err != nil {
+			return
+		}
+		l.out = w
+		l.encoder = enc
+	}
+}
+
+// Write sends the journal entry p
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return 0, ErrWriterClosed
+	}
+
+	_, err := w.conn.Write(p)
+	if err != nil && isMessageTooLarge(err) {
+		err = sendJournalFile(w.conn, p)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to journald. Subsequent writes fail with
+// ErrWriterClosed.
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
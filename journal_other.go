@@ -0,0 +1,19 @@
+//go:build !unix
+
+package jlo
+
+import (
+	"errors"
+	"net"
+)
+
+// isMessageTooLarge reports whether err is caused by a datagram exceeding the
+// maximum size
+func isMessageTooLarge(err error) bool {
+	return false
+}
+
+// sendJournalFile is not supported without unix sockets
+func sendJournalFile(conn *net.UnixConn, p []byte) error {
+	return errors.New("passing files to journald is not supported")
+}
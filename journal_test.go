@@ -0,0 +1,75 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JournalEncoder(t *testing.T) {
+
+	tests := map[string]struct {
+		Encoder  jlo.JournalEncoder
+		Logger   func(l *jlo.Logger) *jlo.Logger
+		Log      func(l *jlo.Logger)
+		Expected string
+	}{
+		"simple": {
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: "PRIORITY=6\nMESSAGE=I'm real\n",
+		},
+		"identifier": {
+			Encoder:  jlo.JournalEncoder{Identifier: "app"},
+			Log:      func(l *jlo.Logger) { l.Errorf("I'm real") },
+			Expected: "PRIORITY=3\nSYSLOG_IDENTIFIER=app\nMESSAGE=I'm real\n",
+		},
+		"fields": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithFields(jlo.Entry{
+					"@request_id": "e44c2a9",
+					"count":       42,
+					"error":       errors.New("I'm broken"),
+					"tags":        []string{"a", "b"},
+					"9-lives.cat": true,
+					"_private":    "x",
+					"@":           "skipped",
+				})
+			},
+			Log: func(l *jlo.Logger) { l.Warnf("I'm real") },
+			Expected: "PRIORITY=4\nMESSAGE=I'm real\n" +
+				"LIVES_CAT=true\nREQUEST_ID=e44c2a9\nPRIVATE=x\nCOUNT=42\nERROR=I'm broken\nTAGS=[\"a\",\"b\"]\n",
+		},
+		"multiline": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("@stacktrace", "main.main\n\tmain.go:42")
+			},
+			Log: func(l *jlo.Logger) { l.Infof("I'm\nreal") },
+			Expected: "PRIORITY=6\nMESSAGE\n\x08\x00\x00\x00\x00\x00\x00\x00I'm\nreal\n" +
+				"STACKTRACE\n\x15\x00\x00\x00\x00\x00\x00\x00main.main\n\tmain.go:42\n",
+		},
+		"overwritten message": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("@message", "custom")
+			},
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: "PRIORITY=6\nMESSAGE=custom\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf, jlo.WithEncoder(test.Encoder))
+			if test.Logger != nil {
+				l = test.Logger(l)
+			}
+
+			test.Log(l)
+			assert.Equal(t, test.Expected, buf.String())
+		})
+	}
+}
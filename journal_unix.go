@@ -0,0 +1,56 @@
+//go:build unix
+
+package jlo
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// isMessageTooLarge reports whether err is caused by a datagram exceeding the
+// maximum size
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile writes p to an unlinked temporary file and passes its file
+// descriptor to journald, which accepts entries of any size this way
+func sendJournalFile(conn *net.UnixConn, p []byte) error {
+	dir := "/dev/shm"
+	if _, err := os.Stat(dir); err != nil {
+		dir = os.TempDir()
+	}
+
+	f, err := os.CreateTemp(dir, "jlo-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// journald only accepts files which are not linked anywhere
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+
+	// WriteMsgUnix refuses connected datagram sockets, so the message is sent
+	// on the raw socket
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	rights := syscall.UnixRights(int(f.Fd()))
+	werr := raw.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
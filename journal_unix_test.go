@@ -0,0 +1,102 @@
+//go:build unix
+
+package jlo_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenJournal listens on a datagram socket in a temporary directory
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	socket := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, socket
+}
+
+// readJournal reads an entry sent as datagram or as file descriptor
+func readJournal(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	if oobn == 0 {
+		return string(buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	assert.Equal(t, uint64(0), uint64(info.Sys().(*syscall.Stat_t).Nlink))
+
+	b := make([]byte, info.Size())
+	_, err = f.ReadAt(b, 0)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func Test_JournalWriter(t *testing.T) {
+	conn, socket := listenJournal(t)
+
+	l := jlo.NewLogger(os.Stdout, jlo.WithJournal(jlo.JournalConfig{Socket: socket}, jlo.JournalEncoder{}))
+	l.WithField("@request_id", "e44c2a9").Errorf("I'm real")
+
+	assert.Equal(t, "PRIORITY=3\nMESSAGE=I'm real\nREQUEST_ID=e44c2a9\n", readJournal(t, conn))
+}
+
+func Test_JournalWriter_Oversized(t *testing.T) {
+	conn, socket := listenJournal(t)
+
+	w, err := jlo.NewJournalWriter(jlo.JournalConfig{Socket: socket})
+	require.NoError(t, err)
+	defer w.Close()
+
+	l := jlo.NewLogger(w, jlo.WithEncoder(jlo.JournalEncoder{}))
+	msg := strings.Repeat("I'm real ", 1<<17)
+	l.Infof(msg)
+
+	assert.Equal(t, "PRIORITY=6\nMESSAGE="+msg+"\n", readJournal(t, conn))
+}
+
+func Test_JournalWriter_Closed(t *testing.T) {
+	_, socket := listenJournal(t)
+
+	w, err := jlo.NewJournalWriter(jlo.JournalConfig{Socket: socket})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("MESSAGE=I'm real\n"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+}
+
+func Test_WithJournal_Unavailable(t *testing.T) {
+	buf := &lockedBuffer{}
+	l := jlo.NewLogger(buf, jlo.WithJournal(jlo.JournalConfig{Socket: filepath.Join(t.TempDir(), "missing")}, jlo.JournalEncoder{}))
+
+	l.Infof("I'm real")
+	assert.Contains(t, buf.String(), `"@message":"I'm real"`)
+}
@@ -0,0 +1,94 @@
+package jlo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownLevel is returned when a log level cannot be parsed
+var ErrUnknownLevel = errors.New("unknown log level")
+
+// ParseLogLevel parses a log level from its name, a common alias like "warn" or
+// "err", or its numeric value. Parsing is case-insensitive.
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug", "dbg", "trace":
+		return DebugLevel, nil
+	case "info", "inf", "information":
+		return InfoLevel, nil
+	case "warning", "warn", "wrn":
+		return WarningLevel, nil
+	case "error", "err", "erro":
+		return ErrorLevel, nil
+	case "fatal", "ftl", "critical", "crit":
+		return FatalLevel, nil
+	case "panic", "pnc":
+		return PanicLevel, nil
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		if level := LogLevel(n); level.valid() {
+			return level, nil
+		}
+	}
+
+	return UnknownLevel, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+}
+
+// valid reports whether the level is one of the defined log levels
+func (l LogLevel) valid() bool {
+	return l >= DebugLevel && l <= PanicLevel
+}
+
+// MarshalText implements encoding.TextMarshaler. UnknownLevel is marshaled as
+// "unknown", so structs with an unset log level can be marshaled.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if !l.valid() && l != UnknownLevel {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownLevel, int(l))
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Besides the names accepted
+// by ParseLogLevel, "unknown" is accepted as UnknownLevel.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	if strings.EqualFold(strings.TrimSpace(string(text)), UnknownLevel.String()) {
+		*l = UnknownLevel
+		return nil
+	}
+
+	level, err := ParseLogLevel(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = level
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both level names and numeric
+// values are accepted.
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnknownLevel, data)
+		}
+		return l.UnmarshalText([]byte(s))
+	}
+
+	return l.UnmarshalText(data)
+}
+
+// Set implements flag.Value, so log levels can be used as command-line flags
+func (l *LogLevel) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
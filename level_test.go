@@ -0,0 +1,159 @@
+package jlo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseLogLevel(t *testing.T) {
+
+	tests := map[string]struct {
+		String   string
+		LogLevel jlo.LogLevel
+		Err      bool
+	}{
+		"debug":              {String: "debug", LogLevel: jlo.DebugLevel},
+		"info":               {String: "info", LogLevel: jlo.InfoLevel},
+		"warning":            {String: "warning", LogLevel: jlo.WarningLevel},
+		"error":              {String: "error", LogLevel: jlo.ErrorLevel},
+		"fatal":              {String: "fatal", LogLevel: jlo.FatalLevel},
+		"panic":              {String: "panic", LogLevel: jlo.PanicLevel},
+		"warn alias":         {String: "warn", LogLevel: jlo.WarningLevel},
+		"err alias":          {String: "err", LogLevel: jlo.ErrorLevel},
+		"upper case":         {String: "WARNING", LogLevel: jlo.WarningLevel},
+		"mixed case":         {String: "Info", LogLevel: jlo.InfoLevel},
+		"surrounding spaces": {String: " debug\n", LogLevel: jlo.DebugLevel},
+		"numeric":            {String: "4", LogLevel: jlo.ErrorLevel},
+		"numeric unknown":    {String: "0", Err: true},
+		"numeric too high":   {String: "42", Err: true},
+		"empty":              {String: "", Err: true},
+		"unknown":            {String: "verbose", Err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			level, err := jlo.ParseLogLevel(test.String)
+			if test.Err {
+				assert.True(t, errors.Is(err, jlo.ErrUnknownLevel))
+				assert.Equal(t, jlo.UnknownLevel, level)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.LogLevel, level)
+		})
+	}
+}
+
+func Test_LogLevel_JSON(t *testing.T) {
+	var config struct {
+		Level jlo.LogLevel `json:"level"`
+	}
+
+	err := json.Unmarshal([]byte(`{"level": "WARN"}`), &config)
+	require.NoError(t, err)
+	assert.Equal(t, jlo.WarningLevel, config.Level)
+
+	err = json.Unmarshal([]byte(`{"level": 1}`), &config)
+	require.NoError(t, err)
+	assert.Equal(t, jlo.DebugLevel, config.Level)
+
+	err = json.Unmarshal([]byte(`{"level": null}`), &config)
+	require.NoError(t, err)
+	assert.Equal(t, jlo.DebugLevel, config.Level)
+
+	err = json.Unmarshal([]byte(`{"level": "verbose"}`), &config)
+	assert.True(t, errors.Is(err, jlo.ErrUnknownLevel))
+
+	config.Level = jlo.ErrorLevel
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"level": "error"}`, string(data))
+
+	config.Level = jlo.UnknownLevel
+	data, err = json.Marshal(config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"level": "unknown"}`, string(data))
+
+	config.Level = jlo.ErrorLevel
+	require.NoError(t, json.Unmarshal(data, &config))
+	assert.Equal(t, jlo.UnknownLevel, config.Level)
+
+	config.Level = jlo.LogLevel(42)
+	_, err = json.Marshal(config)
+	assert.Error(t, err)
+}
+
+func Test_LogLevel_String(t *testing.T) {
+
+	tests := map[string]struct {
+		LogLevel jlo.LogLevel
+		String   string
+	}{
+		"unknown":   {LogLevel: jlo.UnknownLevel, String: "unknown"},
+		"debug":     {LogLevel: jlo.DebugLevel, String: "debug"},
+		"info":      {LogLevel: jlo.InfoLevel, String: "info"},
+		"warning":   {LogLevel: jlo.WarningLevel, String: "warning"},
+		"error":     {LogLevel: jlo.ErrorLevel, String: "error"},
+		"fatal":     {LogLevel: jlo.FatalLevel, String: "fatal"},
+		"panic":     {LogLevel: jlo.PanicLevel, String: "panic"},
+		"undefined": {LogLevel: jlo.LogLevel(42), String: "unknown"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.String, test.LogLevel.String())
+		})
+	}
+}
+
+func Test_LogLevel_Text(t *testing.T) {
+	for _, level := range []jlo.LogLevel{
+		jlo.DebugLevel,
+		jlo.InfoLevel,
+		jlo.WarningLevel,
+		jlo.ErrorLevel,
+		jlo.FatalLevel,
+		jlo.PanicLevel,
+	} {
+		text, err := level.MarshalText()
+		require.NoError(t, err)
+
+		var parsed jlo.LogLevel
+		require.NoError(t, parsed.UnmarshalText(text))
+		assert.Equal(t, level, parsed)
+	}
+}
+
+func Test_LogLevel_Flag(t *testing.T) {
+	level := jlo.InfoLevel
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	err := fs.Parse([]string{"-level", "debug"})
+	require.NoError(t, err)
+	assert.Equal(t, jlo.DebugLevel, level)
+	assert.Equal(t, "debug", fs.Lookup("level").Value.String())
+}
+
+func Test_LogLevel_Flag_ZeroDefault(t *testing.T) {
+	var level jlo.LogLevel
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	out := bytes.NewBuffer(nil)
+	fs.SetOutput(out)
+	fs.PrintDefaults()
+	assert.NotContains(t, out.String(), "fatal")
+	assert.Equal(t, "unknown", fs.Lookup("level").DefValue)
+}
@@ -0,0 +1,121 @@
+package jlo
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder encodes log entries as logfmt key=value pairs. The timestamp,
+// level and message come first, followed by the custom fields sorted by key.
+// Standard fields overwritten by custom fields are only written once, in the
+// position of the custom field.
+type LogfmtEncoder struct{}
+
+// AppendEntry implements Encoder
+func (LogfmtEncoder) AppendEntry(dst []byte, e *EntryView) []byte {
+	l := e.logger
+	first := true
+
+	if _, ok := e.Field(l.FieldKeyTime); !ok {
+		var scratch [64]byte
+		dst = appendLogfmtKey(dst, l.FieldKeyTime, &first)
+		dst = appendLogfmtJSON(dst, e.AppendTime(scratch[:0]))
+	}
+	if _, ok := e.Field(l.FieldKeyLevel); !ok {
+		dst = appendLogfmtKey(dst, l.FieldKeyLevel, &first)
+		dst = appendLogfmtString(dst, e.level.String())
+	}
+	if _, ok := e.Field(l.FieldKeyMsg); !ok {
+		dst = appendLogfmtKey(dst, l.FieldKeyMsg, &first)
+		dst = appendLogfmtString(dst, string(e.msg.b))
+	}
+
+	fs := e.customFields()
+	for f := fs.peek(); f != nil; f = fs.peek() {
+		dst = appendLogfmtKey(dst, f.key, &first)
+		dst = appendLogfmtValue(dst, f.value)
+		fs.next()
+	}
+
+	return dst
+}
+
+// appendLogfmtKey appends the key followed by an equal sign to dst. Characters
+// which are not allowed in keys are replaced by underscores.
+func appendLogfmtKey(dst []byte, key string, first *bool) []byte {
+	if *first {
+		*first = false
+	} else {
+		dst = append(dst, ' ')
+	}
+
+	if key == "" {
+		return append(dst, "_="...)
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			dst = append(dst, '_')
+		} else {
+			dst = utf8.AppendRune(dst, r)
+		}
+	}
+	return append(dst, '=')
+}
+
+// appendLogfmtValue appends the logfmt representation of v to dst
+func appendLogfmtValue(dst []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendLogfmtString(dst, v)
+	case error:
+		msg, ok := errorMessage(v)
+		if !ok {
+			return append(dst, "null"...)
+		}
+		return appendLogfmtString(dst, msg)
+	case time.Time:
+		return v.AppendFormat(dst, time.RFC3339Nano)
+	}
+
+	var scratch [64]byte
+	js, err := appendJSONValue(scratch[:0], v)
+	if err != nil {
+		return appendLogfmtString(dst, badValue(err))
+	}
+	return appendLogfmtJSON(dst, js)
+}
+
+// appendLogfmtJSON appends the json encoded value js to dst. Strings are
+// unquoted if possible, objects and arrays are quoted as a whole.
+func appendLogfmtJSON(dst []byte, js []byte) []byte {
+	if len(js) >= 2 && js[0] == '"' {
+		if s, err := strconv.Unquote(string(js)); err == nil {
+			return appendLogfmtString(dst, s)
+		}
+	}
+	return appendLogfmtString(dst, string(js))
+}
+
+// appendLogfmtString appends s to dst, quoting it if it is empty or contains
+// spaces, equal signs, quotes, control characters or invalid utf8
+func appendLogfmtString(dst []byte, s string) []byte {
+	if !logfmtNeedsQuotes(s) {
+		return append(dst, s...)
+	}
+	return strconv.AppendQuote(dst, s)
+}
+
+func logfmtNeedsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LogfmtEncoder(t *testing.T) {
+
+	tests := map[string]struct {
+		Logger   func(l *jlo.Logger) *jlo.Logger
+		Message  string
+		Expected string
+	}{
+		"simple": {
+			Message:  "real",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message=real`,
+		},
+		"message with spaces": {
+			Message:  "I'm real",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message="I'm real"`,
+		},
+		"message with quotes and newlines": {
+			Message:  "\"I'm\"\nreal\t\\",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message="\"I'm\"\nreal\t\\"`,
+		},
+		"message with equal sign": {
+			Message:  "a=b",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message="a=b"`,
+		},
+		"empty message": {
+			Message:  "",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message=""`,
+		},
+		"unicode message": {
+			Message:  "Grüße<>&",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message=Grüße<>&`,
+		},
+		"fields": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithFields(jlo.Entry{
+					"string":   "I'm real",
+					"int":      42,
+					"float":    2.1,
+					"bool":     true,
+					"nil":      nil,
+					"error":    errors.New("I'm real"),
+					"time":     time.Date(2018, 8, 2, 21, 48, 56, 0, time.UTC),
+					"duration": time.Second,
+					"map":      map[string]int{"a": 1},
+					"slice":    []string{"I'm", "real"},
+				})
+			},
+			Message: "real",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message=real bool=true duration=1000000000 ` +
+				`error="I'm real" float=2.1 int=42 map="{\"a\":1}" nil=null slice="[\"I'm\",\"real\"]" ` +
+				`string="I'm real" time=2018-08-02T21:48:56Z`,
+		},
+		"invalid keys": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("a key=\"x\"", 1).WithField("", 2)
+			},
+			Message:  "real",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @level=info @message=real _=2 a_key__x_=1`,
+		},
+		"overwritten standard field": {
+			Logger: func(l *jlo.Logger) *jlo.Logger {
+				return l.WithField("@level", "custom")
+			},
+			Message:  "real",
+			Expected: `@timestamp=2018-08-02T21:48:56.856339554Z @message=real @level=custom`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetEncoder(jlo.LogfmtEncoder{})
+			if test.Logger != nil {
+				l = test.Logger(l)
+			}
+
+			l.Infof(test.Message)
+			assert.Equal(t, test.Expected+"\n", buf.String())
+		})
+	}
+}
+
+func Test_LogfmtEncoder_TimeEncoder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetEncoder(jlo.LogfmtEncoder{})
+
+	l.SetTimeEncoder(jlo.UnixMillisTimeEncoder)
+	l.Infof("real")
+	assert.Equal(t, "@timestamp=1533246536856 @level=info @message=real\n", buf.String())
+
+	buf.Reset()
+	l.SetTimeEncoder(jlo.LayoutTimeEncoder(time.ANSIC))
+	l.Infof("real")
+	assert.Equal(t, "@timestamp=\"Thu Aug  2 21:48:56 2018\" @level=info @message=real\n", buf.String())
+}
+
+func Test_Logger_SetEncoder_InheritedByClones(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetEncoder(jlo.LogfmtEncoder{})
+	l.FieldKeyMsg = "msg"
+
+	l.WithField("@request_id", "e44c2a9").Warnf("I'm %s", "real")
+	assert.Equal(t, "@timestamp=2018-08-02T21:48:56.856339554Z @level=warning msg=\"I'm real\" @request_id=e44c2a9\n", buf.String())
+
+	buf.Reset()
+	l.SetEncoder(nil)
+	l.Infof("real")
+	assert.Equal(t, `{"@level":"info","@timestamp":"2018-08-02T21:48:56.856339554Z","msg":"real"}`+"\n", buf.String())
+}
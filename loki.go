@@ -0,0 +1,314 @@
+package jlo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+// LokiEncoder is a BatchEncoder for the Grafana Loki push API, e.g.
+// "http://loki:3100/loki/api/v1/push". Entries must be encoded by
+// JSONEncoder. Fields listed in LabelKeys are removed from the entries and
+// become stream labels, entries are grouped into streams by their labels.
+type LokiEncoder struct {
+	// LabelKeys are the keys of the fields which become stream labels. Label
+	// names are derived from the keys, e.g. "@level" becomes "level".
+	LabelKeys []string
+	// Labels are added to all streams
+	Labels map[string]string
+	// TimeKey is the key of the timestamp field. It defaults to FieldKeyTime.
+	TimeKey string
+	// Protobuf enables snappy compressed protobuf bodies instead of JSON. The
+	// HTTPWriter should not compress these bodies any further.
+	Protobuf bool
+}
+
+// lokiLabel is a stream label
+type lokiLabel struct {
+	name  string
+	value string
+}
+
+// lokiEntry is a log line with its timestamp in nanoseconds
+type lokiEntry struct {
+	ts   int64
+	line []byte
+}
+
+// lokiStream is a set of entries sharing the same labels
+type lokiStream struct {
+	labels  []lokiLabel
+	entries []lokiEntry
+}
+
+// ContentType implements BatchEncoder
+func (enc LokiEncoder) ContentType() string {
+	if enc.Protobuf {
+		return "application/x-protobuf"
+	}
+	return "application/json"
+}
+
+// AppendBatch implements BatchEncoder
+func (enc LokiEncoder) AppendBatch(dst []byte, entries []BatchEntry) ([]byte, error) {
+	streams := enc.streams(entries)
+	if enc.Protobuf {
+		return append(dst, snappy.Encode(nil, appendLokiProtobuf(nil, streams))...), nil
+	}
+	return appendLokiJSON(dst, streams), nil
+}
+
+// streams groups the entries by their labels, keeping the order of first
+// appearance
+func (enc LokiEncoder) streams(entries []BatchEntry) []*lokiStream {
+	timeKey := enc.TimeKey
+	if timeKey == "" {
+		timeKey = FieldKeyTime
+	}
+
+	var streams []*lokiStream
+	byLabels := make(map[string]*lokiStream)
+
+	for _, e := range entries {
+		labels := make([]lokiLabel, 0, len(enc.Labels)+len(enc.LabelKeys))
+		for name, value := range enc.Labels {
+			labels = append(labels, lokiLabel{name: lokiLabelName(name), value: value})
+		}
+
+		ts := Now().UnixNano()
+		line := e.Data
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(e.Data, &fields); err == nil {
+			if t, ok := lokiTimestamp(fields[timeKey]); ok {
+				ts = t
+			}
+
+			removed := false
+			for _, key := range enc.LabelKeys {
+				raw, ok := fields[key]
+				if !ok {
+					continue
+				}
+
+				labels = withLokiLabel(labels, lokiLabel{name: lokiLabelName(key), value: lokiLabelValue(raw)})
+				delete(fields, key)
+				removed = true
+			}
+			if removed {
+				line = appendLokiLine(nil, fields)
+			}
+		}
+
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+		key := lokiLabelString(labels)
+
+		s, ok := byLabels[key]
+		if !ok {
+			s = &lokiStream{labels: labels}
+			byLabels[key] = s
+			streams = append(streams, s)
+		}
+		s.entries = append(s.entries, lokiEntry{ts: ts, line: line})
+	}
+
+	return streams
+}
+
+// withLokiLabel adds the label, replacing a label of the same name
+func withLokiLabel(labels []lokiLabel, label lokiLabel) []lokiLabel {
+	for i := range labels {
+		if labels[i].name == label.name {
+			labels[i] = label
+			return labels
+		}
+	}
+	return append(labels, label)
+}
+
+// lokiTimestamp returns the nanoseconds of an RFC 3339 timestamp, a unix
+// timestamp in integer seconds, milliseconds, microseconds or nanoseconds, or
+// a unix timestamp in fractional seconds
+func lokiTimestamp(raw json.RawMessage) (int64, bool) {
+	if len(raw) == 0 {
+		return 0, false
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, false
+		}
+		return t.UnixNano(), true
+	}
+
+	n, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		// fractional seconds
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return 0, false
+		}
+		return int64(f * float64(time.Second)), true
+	}
+	switch {
+	case n > 1e17:
+		return n, true
+	case n > 1e14:
+		return n * int64(time.Microsecond), true
+	case n > 1e11:
+		return n * int64(time.Millisecond), true
+	default:
+		return n * int64(time.Second), true
+	}
+}
+
+// lokiLabelName converts the key to a valid label name. Leading characters
+// other than letters are removed, others which are not allowed are replaced
+// by underscores.
+func lokiLabelName(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			sb.WriteByte(c)
+		case sb.Len() == 0:
+			// label names must start with a letter or underscore
+		case c >= '0' && c <= '9', c == '_':
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
+
+// lokiLabelValue returns strings unquoted and other values as json
+func lokiLabelValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// lokiLabelString formats the labels like Prometheus, e.g.
+// {level="info", service="api"}
+func lokiLabelString(labels []lokiLabel) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(l.name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l.value))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// appendLokiLine appends the json object of the fields sorted by key, like
+// JSONEncoder sorts them
+func appendLokiLine(dst []byte, fields map[string]json.RawMessage) []byte {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	dst = append(dst, '{')
+	for i, key := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, key)
+		dst = append(dst, ':')
+		dst = append(dst, fields[key]...)
+	}
+	return append(dst, '}')
+}
+
+// appendLokiJSON appends the push request in json format
+func appendLokiJSON(dst []byte, streams []*lokiStream) []byte {
+	dst = append(dst, `{"streams":[`...)
+	for i, s := range streams {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		dst = append(dst, `{"stream":{`...)
+		for j, l := range s.labels {
+			if j > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, l.name)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, l.value)
+		}
+
+		dst = append(dst, `},"values":[`...)
+		for j, e := range s.entries {
+			if j > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, `["`...)
+			dst = strconv.AppendInt(dst, e.ts, 10)
+			dst = append(dst, `",`...)
+			dst = appendJSONString(dst, string(e.line))
+			dst = append(dst, ']')
+		}
+		dst = append(dst, "]}"...)
+	}
+	return append(dst, "]}"...)
+}
+
+// appendLokiProtobuf appends the push request in protobuf format:
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+//	message Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func appendLokiProtobuf(dst []byte, streams []*lokiStream) []byte {
+	var stream, entry, ts []byte
+	for _, s := range streams {
+		stream = appendProtoBytes(stream[:0], 1, []byte(lokiLabelString(s.labels)))
+		for _, e := range s.entries {
+			ts = appendProtoVarint(ts[:0], 1, uint64(e.ts/int64(time.Second)))
+			ts = appendProtoVarint(ts, 2, uint64(e.ts%int64(time.Second)))
+
+			entry = appendProtoBytes(entry[:0], 1, ts)
+			entry = appendProtoBytes(entry, 2, e.line)
+			stream = appendProtoBytes(stream, 2, entry)
+		}
+		dst = appendProtoBytes(dst, 1, stream)
+	}
+	return dst
+}
+
+// appendProtoVarint appends a varint field, omitting zero values
+func appendProtoVarint(dst []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return dst
+	}
+	dst = binary.AppendUvarint(dst, uint64(field)<<3)
+	return binary.AppendUvarint(dst, v)
+}
+
+// appendProtoBytes appends a length delimited field
+func appendProtoBytes(dst []byte, field int, b []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(field)<<3|2)
+	dst = binary.AppendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
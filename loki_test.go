@@ -0,0 +1,258 @@
+package jlo_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+	"github.com/golang/snappy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lokiStream is a decoded stream of a push request, the labels are formatted
+// like Prometheus labels
+type lokiStream struct {
+	Labels string
+	Values [][2]string
+}
+
+// fakeLoki decodes json and protobuf push requests
+type fakeLoki struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	streams []lokiStream
+}
+
+func newFakeLoki(t *testing.T) *fakeLoki {
+	l := &fakeLoki{}
+	l.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var streams []lokiStream
+		switch r.Header.Get("Content-Type") {
+		case "application/json":
+			streams = decodeLokiJSON(t, body)
+		case "application/x-protobuf":
+			pb, err := snappy.Decode(nil, body)
+			require.NoError(t, err)
+			streams = decodeLokiProtobuf(t, pb)
+		default:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		l.mu.Lock()
+		l.streams = append(l.streams, streams...)
+		l.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(l.Close)
+	return l
+}
+
+func (l *fakeLoki) received() []lokiStream {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]lokiStream(nil), l.streams...)
+}
+
+func decodeLokiJSON(t *testing.T, body []byte) []lokiStream {
+	var req struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal(body, &req))
+
+	var streams []lokiStream
+	for _, s := range req.Streams {
+		// labels are sorted by name like in the protobuf format
+		var labels []byte
+		keys := make([]string, 0, len(s.Stream))
+		for key := range s.Stream {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			if i > 0 {
+				labels = append(labels, ", "...)
+			}
+			labels = append(labels, key+"="+strconv.Quote(s.Stream[key])...)
+		}
+		streams = append(streams, lokiStream{Labels: "{" + string(labels) + "}", Values: s.Values})
+	}
+	return streams
+}
+
+// protoFields decodes the fields of a protobuf message, which must only
+// consist of varint and length delimited fields
+func protoFields(t *testing.T, b []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		b = b[n:]
+
+		switch tag & 7 {
+		case 0:
+			_, n := binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			fields[int(tag>>3)] = append(fields[int(tag>>3)], b[:n])
+			b = b[n:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			fields[int(tag>>3)] = append(fields[int(tag>>3)], b[n:n+int(l)])
+			b = b[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+	return fields
+}
+
+func protoVarint(fields map[int][][]byte, field int) uint64 {
+	if len(fields[field]) == 0 {
+		return 0
+	}
+	v, _ := binary.Uvarint(fields[field][0])
+	return v
+}
+
+func decodeLokiProtobuf(t *testing.T, pb []byte) []lokiStream {
+	var streams []lokiStream
+	for _, sb := range protoFields(t, pb)[1] {
+		sf := protoFields(t, sb)
+		s := lokiStream{Labels: string(sf[1][0])}
+		for _, eb := range sf[2] {
+			ef := protoFields(t, eb)
+			tf := protoFields(t, ef[1][0])
+			ts := time.Unix(int64(protoVarint(tf, 1)), int64(protoVarint(tf, 2))).UnixNano()
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(ts, 10), string(ef[2][0])})
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+func Test_LokiEncoder(t *testing.T) {
+
+	tests := map[string]bool{
+		"json":     false,
+		"protobuf": true,
+	}
+
+	for name, protobuf := range tests {
+		t.Run(name, func(t *testing.T) {
+			loki := newFakeLoki(t)
+			w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{
+				URL: loki.URL + "/loki/api/v1/push",
+				Encoder: jlo.LokiEncoder{
+					LabelKeys: []string{"service", "@level"},
+					Labels:    map[string]string{"env": "test"},
+					Protobuf:  protobuf,
+				},
+			})
+			require.NoError(t, err)
+
+			l := jlo.NewLogger(w)
+			l.SetLogLevel(jlo.DebugLevel)
+			api := l.WithField("service", "api")
+			api.Infof("first")
+			l.WithField("service", "worker").Infof("second")
+			api.WithField("@request_id", "e44c2a9").Infof("third")
+			api.Errorf("fourth")
+			require.NoError(t, w.Close())
+
+			ts := strconv.FormatInt(time.Date(2018, 8, 2, 21, 48, 56, 856339554, time.UTC).UnixNano(), 10)
+			assert.Equal(t, []lokiStream{
+				{
+					Labels: `{env="test", level="info", service="api"}`,
+					Values: [][2]string{
+						{ts, `{"@message":"first","@timestamp":"2018-08-02T21:48:56.856339554Z"}`},
+						{ts, `{"@message":"third","@request_id":"e44c2a9","@timestamp":"2018-08-02T21:48:56.856339554Z"}`},
+					},
+				},
+				{
+					Labels: `{env="test", level="info", service="worker"}`,
+					Values: [][2]string{
+						{ts, `{"@message":"second","@timestamp":"2018-08-02T21:48:56.856339554Z"}`},
+					},
+				},
+				{
+					Labels: `{env="test", level="error", service="api"}`,
+					Values: [][2]string{
+						{ts, `{"@message":"fourth","@timestamp":"2018-08-02T21:48:56.856339554Z"}`},
+					},
+				},
+			}, loki.received())
+		})
+	}
+}
+
+func Test_LokiEncoder_Timestamps(t *testing.T) {
+	ts := time.Date(2020, 2, 29, 13, 37, 42, 123456789, time.UTC)
+
+	tests := map[string]struct {
+		Encoder  jlo.TimeEncoder
+		Expected time.Time
+	}{
+		"rfc3339":      {Encoder: jlo.RFC3339NanoTimeEncoder, Expected: ts},
+		"unix seconds": {Encoder: jlo.UnixSecondsTimeEncoder, Expected: ts},
+		"unix millis":  {Encoder: jlo.UnixMillisTimeEncoder, Expected: ts.Truncate(time.Millisecond)},
+		"unix nanos":   {Encoder: jlo.UnixNanosTimeEncoder, Expected: ts},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			loki := newFakeLoki(t)
+			w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: loki.URL, Encoder: jlo.LokiEncoder{}})
+			require.NoError(t, err)
+
+			l := jlo.NewLogger(w)
+			l.SetClock(jlo.NewFrozenClock(ts))
+			l.SetTimeEncoder(test.Encoder)
+			l.Infof("I'm real")
+			require.NoError(t, w.Close())
+
+			streams := loki.received()
+			require.Len(t, streams, 1)
+			assert.Equal(t, "{}", streams[0].Labels)
+			// fractional seconds lose precision
+			ns, err := strconv.ParseInt(streams[0].Values[0][0], 10, 64)
+			require.NoError(t, err)
+			assert.InDelta(t, test.Expected.UnixNano(), ns, float64(time.Microsecond))
+		})
+	}
+}
+
+func Test_LokiEncoder_NonJSONEntries(t *testing.T) {
+	loki := newFakeLoki(t)
+	w, err := jlo.NewHTTPWriter(jlo.HTTPConfig{URL: loki.URL, Encoder: jlo.LokiEncoder{
+		LabelKeys: []string{"service"},
+		Labels:    map[string]string{"job": "app"},
+	}})
+	require.NoError(t, err)
+
+	w.Write([]byte("I'm real\n"))
+	require.NoError(t, w.Close())
+
+	streams := loki.received()
+	require.Len(t, streams, 1)
+	assert.Equal(t, `{job="app"}`, streams[0].Labels)
+	assert.Equal(t, "I'm real", streams[0].Values[0][1])
+	assert.Equal(t, strconv.FormatInt(time.Date(2018, 8, 2, 21, 48, 56, 856339554, time.UTC).UnixNano(), 10), streams[0].Values[0][0])
+}
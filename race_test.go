@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package jlo_test
+
+// raceEnabled reports whether the race detector is enabled, which adds heap
+// allocations of its own
+const raceEnabled = true
@@ -0,0 +1,120 @@
+package jlo
+
+import (
+	"io"
+	"os"
+)
+
+// LevelWriter is implemented by output destinations which handle log entries
+// depending on their level. The logger calls WriteLevel instead of Write for
+// outputs implementing it.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level LogLevel, p []byte) (n int, err error)
+}
+
+// Route sends log entries with a level between MinLevel and MaxLevel to Writer.
+// A MaxLevel of UnknownLevel means there is no upper bound.
+type Route struct {
+	Writer   io.Writer
+	MinLevel LogLevel
+	MaxLevel LogLevel
+}
+
+// matches reports whether entries of the given level are sent along the route
+func (r Route) matches(level LogLevel) bool {
+	return level >= r.MinLevel && (r.MaxLevel == UnknownLevel || level <= r.MaxLevel)
+}
+
+// Router is a LevelWriter which writes each log entry to the writers of all
+// matching routes
+type Router struct {
+	routes []Route
+}
+
+// NewRouter creates a new router sending log entries along the given routes
+func NewRouter(routes ...Route) *Router {
+	return &Router{routes: routes}
+}
+
+// NewStdRouter creates a new router sending errors and above to stderr and
+// everything else to stdout
+func NewStdRouter() *Router {
+	return NewRouter(
+		Route{Writer: os.Stdout, MaxLevel: WarningLevel},
+		Route{Writer: os.Stderr, MinLevel: ErrorLevel},
+	)
+}
+
+// WithRoutes returns an option which makes the logger write to a router with
+// the given routes instead of the output passed to NewLogger
+func WithRoutes(routes ...Route) Option {
+	return func(l *Logger) {
+		l.out = NewRouter(routes...)
+	}
+}
+
+// SetRoutes makes the logger write to a router with the given routes
+func (l *Logger) SetRoutes(routes ...Route) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out = NewRouter(routes...)
+}
+
+// WriteLevel writes p to the writers of all routes matching the level, passing
+// the level on to writers which are LevelWriters themselves. All writers are
+// written to even if some of them fail, the first error is returned.
+func (r *Router) WriteLevel(level LogLevel, p []byte) (int, error) {
+	var err error
+	for _, route := range r.routes {
+		if route.Writer == nil || !route.matches(level) {
+			continue
+		}
+
+		var werr error
+		if lw, ok := route.Writer.(LevelWriter); ok {
+			_, werr = lw.WriteLevel(level, p)
+		} else {
+			_, werr = route.Writer.Write(p)
+		}
+		if werr != nil && err == nil {
+			err = werr
+		}
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Write writes p to the writers of all routes regardless of their levels
+func (r *Router) Write(p []byte) (int, error) {
+	var err error
+	for _, route := range r.routes {
+		if route.Writer == nil {
+			continue
+		}
+
+		if _, werr := route.Writer.Write(p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync flushes data buffered by the writers of all routes, if they support it
+func (r *Router) Sync() error {
+	var err error
+	for _, route := range r.routes {
+		if ferr := flushWriter(route.Writer); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
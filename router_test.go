@@ -0,0 +1,170 @@
+package jlo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("I'm broken")
+}
+
+func Test_Logger_WithRoutes(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	all := bytes.NewBuffer(nil)
+
+	l := jlo.NewLogger(nil, jlo.WithRoutes(
+		jlo.Route{Writer: stdout, MaxLevel: jlo.WarningLevel},
+		jlo.Route{Writer: stderr, MinLevel: jlo.ErrorLevel},
+		jlo.Route{Writer: all, MinLevel: jlo.InfoLevel},
+	))
+	l.SetLogLevel(jlo.DebugLevel)
+
+	l.Debugf("debug")
+	l.Infof("info")
+	l.Warnf("warning")
+	l.Errorf("error")
+	l.WithField("@request_id", "e44c2a9").Errorf("error")
+
+	messages := func(buf *bytes.Buffer) []string {
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			msgs = append(msgs, entry["@message"].(string))
+		}
+		return msgs
+	}
+
+	assert.Equal(t, []string{"debug", "info", "warning"}, messages(stdout))
+	assert.Equal(t, []string{"error", "error"}, messages(stderr))
+	assert.Equal(t, []string{"info", "warning", "error", "error"}, messages(all))
+}
+
+func Test_Logger_SetRoutes(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	routed := bytes.NewBuffer(nil)
+
+	l := jlo.NewLogger(buf)
+	l.SetRoutes(jlo.Route{Writer: routed, MinLevel: jlo.ErrorLevel})
+
+	l.Infof("info")
+	l.Errorf("error")
+
+	assert.Empty(t, buf.String())
+	assert.Equal(t, "error", decodeEntry(t, routed)["@message"])
+}
+
+func Test_Router_WriteLevel_ContinuesAfterError(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	r := jlo.NewRouter(
+		jlo.Route{Writer: failingWriter{}},
+		jlo.Route{Writer: buf},
+	)
+
+	n, err := r.WriteLevel(jlo.InfoLevel, []byte("I'm real\n"))
+	assert.EqualError(t, err, "I'm broken")
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "I'm real\n", buf.String())
+}
+
+// levelRecorder records the levels of all entries written to it
+type levelRecorder struct {
+	bytes.Buffer
+	levels []jlo.LogLevel
+}
+
+func (w *levelRecorder) WriteLevel(level jlo.LogLevel, p []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	return w.Write(p)
+}
+
+func Test_Router_WriteLevel_NestedLevelWriter(t *testing.T) {
+	nested := &levelRecorder{}
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(nil, jlo.WithRoutes(
+		jlo.Route{Writer: nested, MinLevel: jlo.WarningLevel},
+		jlo.Route{Writer: buf},
+	))
+
+	l.Infof("info")
+	l.Warnf("warning")
+	l.Errorf("error")
+
+	assert.Equal(t, []jlo.LogLevel{jlo.WarningLevel, jlo.ErrorLevel}, nested.levels)
+	assert.Equal(t, 2, strings.Count(nested.String(), "\n"))
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+}
+
+func Test_Router_Write(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	r := jlo.NewRouter(
+		jlo.Route{Writer: stdout, MaxLevel: jlo.WarningLevel},
+		jlo.Route{Writer: stderr, MinLevel: jlo.ErrorLevel},
+	)
+
+	n, err := r.Write([]byte("I'm real\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 9, n)
+	assert.Equal(t, "I'm real\n", stdout.String())
+	assert.Equal(t, "I'm real\n", stderr.String())
+}
+
+func Test_Logger_Fatalf_SyncsRoutes(t *testing.T) {
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	l := jlo.NewLogger(nil, jlo.WithRoutes(
+		jlo.Route{Writer: stdout, MaxLevel: jlo.WarningLevel},
+		jlo.Route{Writer: stderr, MinLevel: jlo.ErrorLevel},
+	))
+	l.SetExitFunc(func(code int) {})
+
+	l.Fatalf("I'm real")
+	assert.Equal(t, 1, stdout.synced)
+	assert.Equal(t, 1, stderr.synced)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), `"@level":"fatal"`)
+}
+
+func Test_Logger_WithRoutes_AtomicWrites(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(nil, jlo.WithRoutes(
+		jlo.Route{Writer: stdout},
+		jlo.Route{Writer: stderr, MinLevel: jlo.ErrorLevel},
+	))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := l.WithField("i", i)
+			for j := 0; j < 100; j++ {
+				l.Infof("I'm real")
+				l.Errorf("I'm real")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 2000)
+	assert.Len(t, strings.Split(strings.TrimSpace(stderr.String()), "\n"), 1000)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+}
@@ -0,0 +1,222 @@
+package jlo
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler is a slog.Handler which writes log records through a Logger, so
+// slog records share the same output format as the Logger methods
+type SlogHandler struct {
+	logger *Logger
+	// attrs holds the attributes added with WithAttrs before any group was
+	// opened. They are added to each entry, so the handler keeps following
+	// the settings of the logger.
+	attrs []slog.Attr
+	// groups holds the groups opened with WithGroup along with the attributes
+	// added to each of them
+	groups []slogGroup
+}
+
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler creates a new slog.Handler writing to the passed in logger.
+// Timestamps are taken from the logger, the time of the slog record is ignored.
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+// Enabled reports whether the logger logs messages on the given level
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	h.logger.mu.RLock()
+	defer h.logger.mu.RUnlock()
+
+	return h.logger.logLevel <= slogLevel(level)
+}
+
+// Handle logs the record with all of its attributes and the fields extracted
+// from ctx added as fields
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.logger
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	e := l.newEntry(ctx, slogLevel(r.Level), r.Message)
+	defer putEntry(e)
+
+	h.addFields(e, r)
+	l.writeEntry(e, r.PC)
+	return nil
+}
+
+// WithAttrs returns a new handler with the attributes added to the currently
+// open group or to all entries if no group is open
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	if len(h.groups) == 0 {
+		return &SlogHandler{
+			logger: h.logger,
+			attrs:  append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+		}
+	}
+
+	groups := make([]slogGroup, len(h.groups))
+	copy(groups, h.groups)
+
+	last := &groups[len(groups)-1]
+	last.attrs = append(last.attrs[:len(last.attrs):len(last.attrs)], attrs...)
+
+	return &SlogHandler{logger: h.logger, attrs: h.attrs, groups: groups}
+}
+
+// WithGroup returns a new handler nesting all subsequent attributes in a json
+// object with the given name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]slogGroup, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+
+	return &SlogHandler{
+		logger: h.logger,
+		attrs:  h.attrs,
+		groups: append(groups, slogGroup{name: name}),
+	}
+}
+
+// addFields adds the handler attributes and the record attributes nested in
+// the open groups to the entry. Empty groups are omitted.
+func (h *SlogHandler) addFields(e *EntryView, r slog.Record) {
+	for _, a := range h.attrs {
+		addSlogAttr(e, a)
+	}
+
+	if len(h.groups) == 0 {
+		r.Attrs(func(a slog.Attr) bool {
+			addSlogAttr(e, a)
+			return true
+		})
+		return
+	}
+
+	fields := make(Entry, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, a)
+		return true
+	})
+
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+
+		group := make(Entry, len(g.attrs)+len(fields))
+		for _, a := range g.attrs {
+			addSlogAttr(group, a)
+		}
+		for k, v := range fields {
+			group[k] = v
+		}
+
+		fields = make(Entry, 1)
+		if len(group) > 0 {
+			fields[g.name] = group
+		}
+	}
+
+	for k, v := range fields {
+		e.SetField(k, v)
+	}
+}
+
+// slogFields is the destination of slog attributes, either the fields of an
+// entry or a group
+type slogFields interface {
+	setSlogField(key string, value interface{})
+}
+
+// setSlogField implements slogFields
+func (e Entry) setSlogField(key string, value interface{}) {
+	e[key] = value
+}
+
+// setSlogField implements slogFields
+func (e *EntryView) setSlogField(key string, value interface{}) {
+	e.SetField(key, value)
+}
+
+// addSlogAttr adds the resolved attribute to the fields following the rules of
+// the slog.Handler documentation
+func addSlogAttr(fs slogFields, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		fs.setSlogField(a.Key, slogValue(a.Value))
+		return
+	}
+
+	attrs := a.Value.Group()
+	if a.Key == "" {
+		for _, ga := range attrs {
+			addSlogAttr(fs, ga)
+		}
+		return
+	}
+
+	group := make(Entry, len(attrs))
+	for _, ga := range attrs {
+		addSlogAttr(group, ga)
+	}
+	if len(group) > 0 {
+		fs.setSlogField(a.Key, group)
+	}
+}
+
+// slogValue converts a resolved non-group slog value to a field value
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindString:
+		return v.String()
+	case slog.KindTime:
+		return v.Time()
+	case slog.KindUint64:
+		return v.Uint64()
+	default:
+		return v.Any()
+	}
+}
+
+// slogLevel maps a slog level to the closest log level. Levels above
+// slog.LevelError are mapped to ErrorLevel, as FatalLevel and PanicLevel are
+// reserved for Fatalf and Panicf.
+func slogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarningLevel
+	default:
+		return ErrorLevel
+	}
+}
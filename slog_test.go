@@ -0,0 +1,229 @@
+package jlo_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogValuer struct {
+	ID string
+}
+
+func (v testLogValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", v.ID), slog.Bool("valued", true))
+}
+
+func Test_SlogHandler_MatchesLogger(t *testing.T) {
+	expected := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(expected)
+	l.FieldKeyMsg = "msg"
+	l.WithField("@request_id", "e44c2a9").Infof("I'm %d%% real", 100)
+
+	actual := bytes.NewBuffer(nil)
+	l = jlo.NewLogger(actual)
+	l.FieldKeyMsg = "msg"
+	slog.New(jlo.NewSlogHandler(l)).Info("I'm 100% real", "@request_id", "e44c2a9")
+
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func Test_SlogHandler_Levels(t *testing.T) {
+
+	tests := map[string]struct {
+		SlogLevel slog.Level
+		Level     string
+	}{
+		"debug":         {SlogLevel: slog.LevelDebug, Level: "debug"},
+		"below info":    {SlogLevel: slog.LevelInfo - 1, Level: "debug"},
+		"info":          {SlogLevel: slog.LevelInfo, Level: "info"},
+		"warn":          {SlogLevel: slog.LevelWarn, Level: "warning"},
+		"above warn":    {SlogLevel: slog.LevelWarn + 1, Level: "warning"},
+		"error":         {SlogLevel: slog.LevelError, Level: "error"},
+		"above error":   {SlogLevel: slog.LevelError + 4, Level: "error"},
+		"far below all": {SlogLevel: slog.LevelDebug - 8, Level: "debug"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetLogLevel(jlo.DebugLevel)
+
+			slog.New(jlo.NewSlogHandler(l)).Log(context.Background(), test.SlogLevel, "I'm real")
+
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"@message":   "I'm real",
+				"@level":     "%s",
+				"@timestamp": "%s"
+			}`, test.Level, testTime), buf.String())
+		})
+	}
+}
+
+func Test_SlogHandler_Enabled(t *testing.T) {
+	l := jlo.NewLogger(bytes.NewBuffer(nil))
+	l.SetLogLevel(jlo.WarningLevel)
+	h := jlo.NewSlogHandler(l)
+
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelError))
+}
+
+func Test_SlogHandler_Attrs(t *testing.T) {
+
+	tests := map[string]struct {
+		Log    func(l *slog.Logger)
+		Fields string
+	}{
+		"kinds": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real",
+					slog.String("string", "real"),
+					slog.Int("int", -42),
+					slog.Uint64("uint", 42),
+					slog.Float64("float", 2.1),
+					slog.Bool("bool", true),
+					slog.Duration("duration", time.Second),
+					slog.Time("time", time.Date(2018, 8, 2, 21, 48, 56, 0, time.UTC)),
+					slog.Any("error", fmt.Errorf("I'm real")),
+				)
+			},
+			Fields: `"bool": true, "duration": 1000000000, "error": "I'm real", "float": 2.1,
+				"int": -42, "string": "real", "time": "2018-08-02T21:48:56Z", "uint": 42`,
+		},
+		"group": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real", slog.Group("request", slog.String("id", "e44c2a9"), slog.Int("status", 200)))
+			},
+			Fields: `"request": {"id": "e44c2a9", "status": 200}`,
+		},
+		"empty group": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real", slog.Group("request"))
+			},
+		},
+		"inlined group": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real", slog.Group("", slog.String("id", "e44c2a9")))
+			},
+			Fields: `"id": "e44c2a9"`,
+		},
+		"empty attr": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real", slog.Attr{})
+			},
+		},
+		"log valuer": {
+			Log: func(l *slog.Logger) {
+				l.Info("I'm real", slog.Any("user", testLogValuer{ID: "aa33ee55"}))
+			},
+			Fields: `"user": {"id": "aa33ee55", "valued": true}`,
+		},
+		"with attrs": {
+			Log: func(l *slog.Logger) {
+				l.With("@request_id", "e44c2a9").Info("I'm real", "status", 200)
+			},
+			Fields: `"@request_id": "e44c2a9", "status": 200`,
+		},
+		"with group": {
+			Log: func(l *slog.Logger) {
+				l.WithGroup("request").Info("I'm real", "id", "e44c2a9")
+			},
+			Fields: `"request": {"id": "e44c2a9"}`,
+		},
+		"with empty group": {
+			Log: func(l *slog.Logger) {
+				l.WithGroup("request").Info("I'm real")
+			},
+		},
+		"with nested groups and attrs": {
+			Log: func(l *slog.Logger) {
+				l.With("service", "api").
+					WithGroup("request").
+					With("id", "e44c2a9").
+					WithGroup("response").
+					Info("I'm real", "status", 200)
+			},
+			Fields: `"service": "api", "request": {"id": "e44c2a9", "response": {"status": 200}}`,
+		},
+		"with nested groups and without record attrs": {
+			Log: func(l *slog.Logger) {
+				l.WithGroup("request").
+					With("id", "e44c2a9").
+					WithGroup("response").
+					Info("I'm real")
+			},
+			Fields: `"request": {"id": "e44c2a9"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			test.Log(slog.New(jlo.NewSlogHandler(jlo.NewLogger(buf))))
+
+			fields := ""
+			if test.Fields != "" {
+				fields = "," + test.Fields
+			}
+
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"@message":   "I'm real",
+				"@level":     "info",
+				"@timestamp": "%s"
+				%s
+			}`, testTime, fields), buf.String())
+		})
+	}
+}
+
+func Test_SlogHandler_WithAttrs_KeepsParentUnaffected(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	parent := slog.New(jlo.NewSlogHandler(jlo.NewLogger(buf))).WithGroup("request")
+
+	parent.With("id", "e44c2a9")
+	parent.Info("I'm real", "status", 200)
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":   "I'm real",
+		"@level":     "info",
+		"@timestamp": "%s",
+		"request":    {"status": 200}
+	}`, testTime), buf.String())
+}
+
+func Test_SlogHandler_WithAttrs_FollowsLogLevel(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	child := slog.New(jlo.NewSlogHandler(l)).With("service", "api")
+
+	l.SetLogLevel(jlo.DebugLevel)
+	child.Debug("I'm real")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@message":   "I'm real",
+		"@level":     "debug",
+		"@timestamp": "%s",
+		"service":    "api"
+	}`, testTime), buf.String())
+}
+
+func Test_SlogHandler_Handle_Allocs(t *testing.T) {
+	l := slog.New(jlo.NewSlogHandler(jlo.NewLogger(bytes.NewBuffer(nil))))
+
+	// attributes are added to the entry without cloning the logger
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info("I'm real", "count", 42)
+	})
+	assert.LessOrEqual(t, allocs, 3.0)
+}
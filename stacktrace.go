@@ -0,0 +1,138 @@
+package jlo
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth limits the number of frames captured for stack traces
+const maxStackDepth = 64
+
+// SetStacktraceLevel changes the minimum log level of entries which include a
+// stack trace of the call site. UnknownLevel disables stack traces, which is
+// the default.
+func (l *Logger) SetStacktraceLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stacktraceLevel = level
+}
+
+// logsStacktrace reports whether entries of the given level include a stack
+// trace
+func (l *Logger) logsStacktrace(level LogLevel) bool {
+	return l.stacktraceLevel != UnknownLevel && level >= l.stacktraceLevel
+}
+
+// addStacktrace adds the stack trace field to the entry. The stack trace of an
+// error field is preferred over the stack trace of the call site at pc.
+func (l *Logger) addStacktrace(e *EntryView, pc uintptr) {
+	pcs := fieldsStackTrace(e.fields)
+	if pcs == nil {
+		pcs = fieldsStackTrace(l.fields)
+	}
+	if pcs == nil {
+		pcs = callersFrom(pc)
+	}
+
+	e.addField(FieldKeyStacktrace, formatStackTrace(pcs))
+}
+
+// callersFrom returns the program counters of the current goroutine's stack,
+// starting at the frame of pc if it is part of the stack
+func callersFrom(pc uintptr) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	for i := range pcs {
+		if pcs[i] == pc {
+			return pcs[i:]
+		}
+	}
+	return pcs
+}
+
+// fieldsStackTrace returns the stack trace of the first error field carrying
+// one, see errorStackTrace
+func fieldsStackTrace(fs fields) []uintptr {
+	for _, f := range fs {
+		if err, ok := f.value.(error); ok && !isNilPointer(err) {
+			if pcs := errorStackTrace(err); pcs != nil {
+				return pcs
+			}
+		}
+	}
+	return nil
+}
+
+// errorStackTrace returns the stack trace of the innermost error in the chain
+// of err implementing a StackTrace() method as known from github.com/pkg/errors.
+// The method must return a slice of program counters.
+func errorStackTrace(err error) []uintptr {
+	var pcs []uintptr
+	for err != nil && !isNilPointer(err) {
+		if st := stackTraceMethod(err); st != nil {
+			pcs = st
+		}
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				if st := errorStackTrace(err); st != nil {
+					return st
+				}
+			}
+			break
+		}
+		err = errors.Unwrap(err)
+	}
+	return pcs
+}
+
+// stackTraceMethod calls the StackTrace() method of err if it has one
+func stackTraceMethod(err error) []uintptr {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+
+	st := m.Type().Out(0)
+	if st.Kind() != reflect.Slice || st.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	out := m.Call(nil)[0]
+	pcs := make([]uintptr, out.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(out.Index(i).Uint())
+	}
+	return pcs
+}
+
+// formatStackTrace formats the stack frames of pcs with one line for the
+// function name followed by one indented line for file and line of each frame
+func formatStackTrace(pcs []uintptr) string {
+	var sb strings.Builder
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			if sb.Len() > 0 {
+				sb.WriteByte('\n')
+			}
+			sb.WriteString(frame.Function)
+			sb.WriteString("\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frame and stackTrace mimic the types of github.com/pkg/errors
+type frame uintptr
+
+type stackTrace []frame
+
+type stackError struct {
+	msg   string
+	stack []uintptr
+}
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 32)
+	return &stackError{msg: msg, stack: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func (e *stackError) Error() string {
+	return e.msg
+}
+
+func (e *stackError) StackTrace() stackTrace {
+	st := make(stackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		st[i] = frame(pc)
+	}
+	return st
+}
+
+// stacktraceFunctions returns the function names of the stack trace field
+func stacktraceFunctions(t *testing.T, entry map[string]interface{}) []string {
+	st, ok := entry["@stacktrace"].(string)
+	require.True(t, ok, "missing stack trace")
+
+	var funcs []string
+	for _, line := range strings.Split(st, "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			funcs = append(funcs, line)
+		}
+	}
+	return funcs
+}
+
+func Test_Logger_SetStacktraceLevel(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetStacktraceLevel(jlo.ErrorLevel)
+
+	l.Warnf("I'm real")
+	assert.NotContains(t, decodeEntry(t, buf), "@stacktrace")
+
+	l.Errorf("I'm real")
+	entry := decodeEntry(t, buf)
+	funcs := stacktraceFunctions(t, entry)
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.Test_Logger_SetStacktraceLevel", funcs[0])
+	assert.Equal(t, "testing.tRunner", funcs[1])
+
+	caller := nextLineCaller()
+	l.WithField("@request_id", "e44c2a9").Errorf("I'm real")
+	st := decodeEntry(t, buf)["@stacktrace"].(string)
+	assert.True(t, strings.HasPrefix(st, "github.com/dcmn-com/jlo_test.Test_Logger_SetStacktraceLevel\n\t"))
+	assert.Contains(t, strings.Split(st, "\n")[1], strings.SplitN(caller, "/", 2)[1])
+}
+
+func Test_Logger_SetStacktraceLevel_Disabled(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+
+	l.Errorf("I'm real")
+	assert.NotContains(t, decodeEntry(t, buf), "@stacktrace")
+}
+
+func Test_Logger_SetStacktraceLevel_PrefersErrorStackTrace(t *testing.T) {
+
+	tests := map[string]error{
+		"error":         newStackError("I'm real"),
+		"wrapped error": fmt.Errorf("wrapped: %w", newStackError("I'm real")),
+		"joined error":  errors.Join(errors.New("plain"), newStackError("I'm real")),
+	}
+
+	for name, err := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetStacktraceLevel(jlo.ErrorLevel)
+
+			l.WithField("error", err).Errorf("I'm real")
+
+			funcs := stacktraceFunctions(t, decodeEntry(t, buf))
+			assert.Equal(t, "github.com/dcmn-com/jlo_test.newStackError", funcs[0])
+		})
+	}
+}
+
+func Test_Logger_SetStacktraceLevel_IgnoresErrorsWithoutStackTrace(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetStacktraceLevel(jlo.ErrorLevel)
+
+	l.WithField("error", errors.New("I'm real")).Errorf("I'm real")
+
+	funcs := stacktraceFunctions(t, decodeEntry(t, buf))
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.Test_Logger_SetStacktraceLevel_IgnoresErrorsWithoutStackTrace", funcs[0])
+}
+
+func Test_SlogHandler_Stacktrace(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetStacktraceLevel(jlo.ErrorLevel)
+
+	slog.New(jlo.NewSlogHandler(l)).Error("I'm real")
+
+	funcs := stacktraceFunctions(t, decodeEntry(t, buf))
+	assert.Equal(t, "github.com/dcmn-com/jlo_test.Test_SlogHandler_Stacktrace", funcs[0])
+}
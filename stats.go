@@ -0,0 +1,54 @@
+package jlo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Stats holds the counters of errors which occurred while logging
+type Stats struct {
+	// WriteErrors is the number of entries which could not be written to the
+	// output
+	WriteErrors uint64
+	// MarshalErrors is the number of field values which could not be
+	// marshaled and were replaced by a "!BADVALUE(...)" marker
+	MarshalErrors uint64
+}
+
+// loggerStats holds the counters shared by a logger and its clones
+type loggerStats struct {
+	writeErrors, marshalErrors atomic.Uint64
+}
+
+// Stats returns the error counters of the logger, which are shared with all
+// loggers created from it with WithField and the like
+func (l *Logger) Stats() Stats {
+	return Stats{
+		WriteErrors:   l.stats.writeErrors.Load(),
+		MarshalErrors: l.stats.marshalErrors.Load(),
+	}
+}
+
+// reportWriteError counts the failed write and reports it to the error handler
+func (l *Logger) reportWriteError(err error) {
+	l.stats.writeErrors.Add(1)
+	l.handleError(fmt.Errorf("write: %w", err))
+}
+
+// reportMarshalError counts and reports the error of a field whose value could
+// not be marshaled, if any
+func (l *Logger) reportMarshalError(f *field) {
+	if f == nil || f.err == nil {
+		return
+	}
+	l.stats.marshalErrors.Add(1)
+	l.handleError(fmt.Errorf("marshal field %q: %w", f.key, f.err))
+}
+
+// reportMarshalErrors reports the marshal errors of the fields with the keys
+// of e
+func (l *Logger) reportMarshalErrors(fs fields, e Entry) {
+	for key := range e {
+		l.reportMarshalError(fs.find(key))
+	}
+}
@@ -0,0 +1,101 @@
+package jlo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingMarshaler fails to marshal itself
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("I'm broken")
+}
+
+func Test_Logger_Stats(t *testing.T) {
+
+	tests := map[string]struct {
+		Out      func() *bytes.Buffer
+		Log      func(l *jlo.Logger)
+		Expected jlo.Stats
+		Errors   []string
+		Output   string
+	}{
+		"write error": {
+			Log:      func(l *jlo.Logger) { l.Infof("I'm real") },
+			Expected: jlo.Stats{WriteErrors: 1},
+			Errors:   []string{"write: I'm broken"},
+		},
+		"marshal error": {
+			Out: func() *bytes.Buffer { return bytes.NewBuffer(nil) },
+			Log: func(l *jlo.Logger) {
+				l.WithFields(jlo.Entry{"value": failingMarshaler{}, "valid": 42}).Infof("I'm real")
+			},
+			Expected: jlo.Stats{MarshalErrors: 1},
+			Errors:   []string{`marshal field "value": json: error calling MarshalJSON for type *jlo_test.failingMarshaler: I'm broken`},
+			Output:   `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z","valid":42,"value":"!BADVALUE(json: error calling MarshalJSON for type *jlo_test.failingMarshaler: I'm broken)"}` + "\n",
+		},
+		"marshal error of hook field": {
+			Out: func() *bytes.Buffer { return bytes.NewBuffer(nil) },
+			Log: func(l *jlo.Logger) {
+				l.AddHook(funcHook{levels: jlo.AllLevels(), fn: func(e *jlo.EntryView) error {
+					e.SetField("channel", make(chan int))
+					return nil
+				}})
+				l.Infof("I'm real")
+			},
+			Expected: jlo.Stats{MarshalErrors: 1},
+			Errors:   []string{`marshal field "channel": json: unsupported type: chan int`},
+			Output:   `{"@level":"info","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z","channel":"!BADVALUE(json: unsupported type: chan int)"}` + "\n",
+		},
+		"shared with clones": {
+			Log: func(l *jlo.Logger) {
+				l.WithField("service", "api").Infof("I'm real")
+				l.WithField("channel", make(chan int)).Infof("I'm real")
+			},
+			Expected: jlo.Stats{WriteErrors: 2, MarshalErrors: 1},
+			Errors: []string{
+				"write: I'm broken",
+				`marshal field "channel": json: unsupported type: chan int`,
+				"write: I'm broken",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var l *jlo.Logger
+			var buf *bytes.Buffer
+			if test.Out != nil {
+				buf = test.Out()
+				l = jlo.NewLogger(buf)
+			} else {
+				l = jlo.NewLogger(failingWriter{})
+			}
+
+			var errs []string
+			l.SetErrorHandler(func(err error) { errs = append(errs, err.Error()) })
+
+			test.Log(l)
+			assert.Equal(t, test.Expected, l.Stats())
+			assert.Equal(t, test.Errors, errs)
+			if buf != nil {
+				assert.Equal(t, test.Output, buf.String())
+			}
+		})
+	}
+}
+
+func Test_LogfmtEncoder_UnsupportedValue(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf, jlo.WithEncoder(jlo.LogfmtEncoder{}))
+	l.SetErrorHandler(func(err error) {})
+	l.WithField("channel", make(chan int)).Infof("I'm real")
+
+	assert.Contains(t, buf.String(), `channel="!BADVALUE(json: unsupported type: chan int)"`)
+}
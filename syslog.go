@@ -0,0 +1,274 @@
+package jlo
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// syslogTimeFormat is the RFC 5424 timestamp layout with the maximum allowed
+// precision of microseconds
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// syslogSockets are the paths of the local syslog socket tried in order
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Facility is a syslog facility
+type Facility int
+
+// Syslog facilities as defined by RFC 5424
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityNTP
+	FacilityAudit
+	FacilityAlert
+	FacilityClock
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogConfig configures a SyslogWriter
+type SyslogConfig struct {
+	// Network is "unix", "unixgram", "udp" or "tcp". If empty, the local
+	// syslog socket like /dev/log is used.
+	Network string
+	// Address is the address of the syslog server
+	Address string
+	// Facility of all messages. FacilityKern is reserved for the kernel, so
+	// it is replaced by FacilityUser.
+	Facility Facility
+	// AppName defaults to the name of the executable
+	AppName string
+	// ProcID defaults to the process id
+	ProcID string
+	// Hostname defaults to the hostname reported by the kernel
+	Hostname string
+	// Timeout limits dialing and writing. Zero means no timeout.
+	Timeout time.Duration
+	// Clock provides the timestamp of messages. It defaults to the
+	// package-level Now function.
+	Clock Clock
+}
+
+// SyslogWriter sends log entries to a syslog server wrapped in RFC 5424
+// frames. Stream connections use octet counting framing as defined by RFC
+// 6587. Failed connections are reestablished on the next write.
+type SyslogWriter struct {
+	config SyslogConfig
+	header []byte
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool
+	closed bool
+	buf    []byte
+}
+
+// NewSyslogWriter creates a new syslog writer and connects to the server
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	if config.Facility == FacilityKern {
+		config.Facility = FacilityUser
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.ProcID == "" {
+		config.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+
+	w := &SyslogWriter{config: config}
+
+	// hostname, app name and proc id never change
+	w.header = appendSyslogHeaderField(w.header, config.Hostname, 255)
+	w.header = append(w.header, ' ')
+	w.header = appendSyslogHeaderField(w.header, config.AppName, 48)
+	w.header = append(w.header, ' ')
+	w.header = appendSyslogHeaderField(w.header, config.ProcID, 128)
+	w.header = append(w.header, " - - "...)
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the syslog server. The caller must hold w.mu.
+func (w *SyslogWriter) connect() error {
+	if w.config.Network != "" {
+		conn, err := net.DialTimeout(w.config.Network, w.config.Address, w.config.Timeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		w.stream = w.config.Network == "tcp" || w.config.Network == "tcp4" ||
+			w.config.Network == "tcp6" || w.config.Network == "unix"
+		return nil
+	}
+
+	for _, addr := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, addr, w.config.Timeout)
+			if err == nil {
+				w.conn = conn
+				w.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+	return errors.New("no local syslog socket found")
+}
+
+// Write sends p with severity informational
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(InfoLevel, p)
+}
+
+// WriteLevel sends p with the syslog severity of the log level. A trailing
+// newline is removed. If sending fails, the connection is reestablished and
+// sending is retried once.
+func (w *SyslogWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	w.buf = w.appendFrame(w.buf[:0], level, bytes.TrimSuffix(p, []byte{'\n'}))
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+
+		if w.config.Timeout > 0 {
+			w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+		}
+		if _, err = w.conn.Write(w.buf); err == nil {
+			return len(p), nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// now returns the current time of the configured clock
+func (w *SyslogWriter) now() time.Time {
+	if w.config.Clock != nil {
+		return w.config.Clock.Now()
+	}
+	return Now()
+}
+
+// appendFrame appends the RFC 5424 message with msg as its content, prefixed
+// by the message length for stream connections
+func (w *SyslogWriter) appendFrame(dst []byte, level LogLevel, msg []byte) []byte {
+	start := len(dst)
+
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(w.config.Facility)*8+int64(syslogSeverity(level)), 10)
+	dst = append(dst, ">1 "...)
+	dst = w.now().AppendFormat(dst, syslogTimeFormat)
+	dst = append(dst, ' ')
+	dst = append(dst, w.header...)
+	dst = append(dst, msg...)
+
+	if !w.stream {
+		return dst
+	}
+
+	// prepend the octet count
+	var count [20]byte
+	prefix := strconv.AppendInt(count[:0], int64(len(dst)-start), 10)
+	prefix = append(prefix, ' ')
+	dst = append(dst, prefix...)
+	copy(dst[start+len(prefix):], dst[start:len(dst)-len(prefix)])
+	copy(dst[start:], prefix)
+	return dst
+}
+
+// Close closes the connection to the syslog server. Subsequent writes fail
+// with ErrWriterClosed.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogSeverity maps the log level to a syslog severity
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case DebugLevel:
+		return 7
+	case InfoLevel:
+		return 6
+	case WarningLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	case FatalLevel:
+		return 2
+	case PanicLevel:
+		return 1
+	default:
+		return 6
+	}
+}
+
+// appendSyslogHeaderField appends the header field limited to max printable
+// ASCII characters, replacing others by underscores. Empty fields are written
+// as the nil value "-".
+func appendSyslogHeaderField(dst []byte, s string, max int) []byte {
+	if s == "" {
+		return append(dst, '-')
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			dst = append(dst, c)
+		} else {
+			dst = append(dst, '_')
+		}
+	}
+	return dst
+}
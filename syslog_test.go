@@ -0,0 +1,183 @@
+package jlo_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syslogTestConfig returns a config with fixed header fields
+func syslogTestConfig(network, address string) jlo.SyslogConfig {
+	return jlo.SyslogConfig{
+		Network:  network,
+		Address:  address,
+		Facility: jlo.FacilityLocal0,
+		AppName:  "app",
+		ProcID:   "42",
+		Hostname: "host",
+		Clock:    jlo.NewFrozenClock(clockTestTime),
+		Timeout:  time.Second,
+	}
+}
+
+// readOctetCounted reads a message framed by octet counting
+func readOctetCounted(t *testing.T, r *bufio.Reader) string {
+	count, err := r.ReadString(' ')
+	require.NoError(t, err)
+	n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+	require.NoError(t, err)
+
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	require.NoError(t, err)
+	return string(msg)
+}
+
+func Test_SyslogWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := jlo.NewSyslogWriter(syslogTestConfig("udp", conn.LocalAddr().String()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	l := jlo.NewLogger(w)
+	l.Warnf("I'm real")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, `<132>1 2020-02-29T13:37:42.123456Z host app 42 - - `+
+		`{"@level":"warning","@message":"I'm real","@timestamp":"2018-08-02T21:48:56.856339554Z"}`, string(buf[:n]))
+}
+
+func Test_SyslogWriter_Unixgram(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := jlo.NewSyslogWriter(syslogTestConfig("unixgram", addr))
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.WriteLevel(jlo.ErrorLevel, []byte("I'm real\n"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "<131>1 2020-02-29T13:37:42.123456Z host app 42 - - I'm real", string(buf[:n]))
+}
+
+func Test_SyslogWriter_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	w, err := jlo.NewSyslogWriter(syslogTestConfig("tcp", ln.Addr().String()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	levels := []jlo.LogLevel{jlo.DebugLevel, jlo.InfoLevel, jlo.WarningLevel, jlo.ErrorLevel, jlo.FatalLevel, jlo.PanicLevel}
+	for _, level := range levels {
+		_, err := w.WriteLevel(level, []byte("I'm\nreal\n"))
+		require.NoError(t, err)
+	}
+
+	r := bufio.NewReader(conn)
+	for _, pri := range []string{"<135>", "<134>", "<132>", "<131>", "<130>", "<129>"} {
+		assert.Equal(t, pri+"1 2020-02-29T13:37:42.123456Z host app 42 - - I'm\nreal", readOctetCounted(t, r))
+	}
+}
+
+func Test_SyslogWriter_Reconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	w, err := jlo.NewSyslogWriter(syslogTestConfig("tcp", ln.Addr().String()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	// the server drops the first connection
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	conn.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	// writes to the dropped connection may succeed until the peer resets it
+	var conn2 net.Conn
+	require.Eventually(t, func() bool {
+		w.Write([]byte("I'm real"))
+		select {
+		case conn2 = <-accepted:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	defer conn2.Close()
+
+	w.Write([]byte("I'm real"))
+	assert.Equal(t, "<134>1 2020-02-29T13:37:42.123456Z host app 42 - - I'm real", readOctetCounted(t, bufio.NewReader(conn2)))
+}
+
+func Test_SyslogWriter_HeaderFields(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	config := syslogTestConfig("udp", conn.LocalAddr().String())
+	config.Facility = jlo.FacilityKern
+	config.AppName = "my app"
+	config.Hostname = strings.Repeat("h", 300)
+	w, err := jlo.NewSyslogWriter(config)
+	require.NoError(t, err)
+	defer w.Close()
+
+	w.WriteLevel(jlo.DebugLevel, []byte("I'm real"))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "<15>1 2020-02-29T13:37:42.123456Z "+strings.Repeat("h", 255)+" my_app 42 - - I'm real", string(buf[:n]))
+}
+
+func Test_SyslogWriter_Closed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := jlo.NewSyslogWriter(syslogTestConfig("udp", conn.LocalAddr().String()))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("I'm real"))
+	assert.Equal(t, jlo.ErrWriterClosed, err)
+}
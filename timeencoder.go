@@ -0,0 +1,88 @@
+package jlo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeEncoder appends the json representation of the log entry timestamp t to
+// dst, which is either a quoted string or a number
+type TimeEncoder func(dst []byte, t time.Time) []byte
+
+// SetTimeEncoder changes the encoding of log entry timestamps. A nil encoder
+// restores the default, which is RFC3339NanoTimeEncoder.
+func (l *Logger) SetTimeEncoder(enc TimeEncoder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timeEncoder = enc
+}
+
+// appendTime appends the timestamp t using the logger time encoder
+func (l *Logger) appendTime(dst []byte, t time.Time) []byte {
+	if l.timeEncoder == nil {
+		return RFC3339NanoTimeEncoder(dst, t)
+	}
+	return l.timeEncoder(dst, t)
+}
+
+// RFC3339TimeEncoder encodes timestamps as RFC3339 string with second precision
+func RFC3339TimeEncoder(dst []byte, t time.Time) []byte {
+	return appendJSONTimeLayout(dst, t, time.RFC3339)
+}
+
+// RFC3339NanoTimeEncoder encodes timestamps as RFC3339 string with nanosecond
+// precision, omitting trailing zeros of the fractional second
+func RFC3339NanoTimeEncoder(dst []byte, t time.Time) []byte {
+	return appendJSONTime(dst, t)
+}
+
+// FixedRFC3339TimeEncoder returns a TimeEncoder encoding timestamps as RFC3339
+// string with a fixed number of fractional second digits, which keeps them
+// sortable lexically. Digits are limited to the range from 0 to 9.
+func FixedRFC3339TimeEncoder(digits int) TimeEncoder {
+	if digits < 0 {
+		digits = 0
+	} else if digits > 9 {
+		digits = 9
+	}
+
+	layout := time.RFC3339
+	if digits > 0 {
+		layout = "2006-01-02T15:04:05." + strings.Repeat("0", digits) + "Z07:00"
+	}
+	return LayoutTimeEncoder(layout)
+}
+
+// UnixSecondsTimeEncoder encodes timestamps as floating point number of seconds
+// since the Unix epoch
+func UnixSecondsTimeEncoder(dst []byte, t time.Time) []byte {
+	return appendJSONFloat(dst, float64(t.UnixNano())/float64(time.Second), 64)
+}
+
+// UnixMillisTimeEncoder encodes timestamps as integer number of milliseconds
+// since the Unix epoch
+func UnixMillisTimeEncoder(dst []byte, t time.Time) []byte {
+	return strconv.AppendInt(dst, t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// UnixNanosTimeEncoder encodes timestamps as integer number of nanoseconds since
+// the Unix epoch
+func UnixNanosTimeEncoder(dst []byte, t time.Time) []byte {
+	return strconv.AppendInt(dst, t.UnixNano(), 10)
+}
+
+// LayoutTimeEncoder returns a TimeEncoder encoding timestamps as string
+// formatted with the given layout, see time.Time.Format
+func LayoutTimeEncoder(layout string) TimeEncoder {
+	return func(dst []byte, t time.Time) []byte {
+		return appendJSONTimeLayout(dst, t, layout)
+	}
+}
+
+// appendJSONTimeLayout appends t formatted with layout as json string to dst
+func appendJSONTimeLayout(dst []byte, t time.Time, layout string) []byte {
+	var scratch [64]byte
+	return appendJSONBytes(dst, t.AppendFormat(scratch[:0], layout))
+}
@@ -0,0 +1,100 @@
+package jlo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dcmn-com/jlo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logger_SetTimeEncoder(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		Encoder   jlo.TimeEncoder
+		Timestamp string
+	}{
+		"default": {
+			Timestamp: `"2020-02-29T13:37:42.123456789Z"`,
+		},
+		"rfc3339": {
+			Encoder:   jlo.RFC3339TimeEncoder,
+			Timestamp: `"2020-02-29T13:37:42Z"`,
+		},
+		"rfc3339 nano": {
+			Encoder:   jlo.RFC3339NanoTimeEncoder,
+			Timestamp: `"2020-02-29T13:37:42.123456789Z"`,
+		},
+		"fixed rfc3339 milliseconds": {
+			Encoder:   jlo.FixedRFC3339TimeEncoder(3),
+			Timestamp: `"2020-02-29T13:37:42.123Z"`,
+		},
+		"fixed rfc3339 microseconds": {
+			Encoder:   jlo.FixedRFC3339TimeEncoder(6),
+			Timestamp: `"2020-02-29T13:37:42.123456Z"`,
+		},
+		"fixed rfc3339 without fraction": {
+			Encoder:   jlo.FixedRFC3339TimeEncoder(0),
+			Timestamp: `"2020-02-29T13:37:42Z"`,
+		},
+		"fixed rfc3339 too many digits": {
+			Encoder:   jlo.FixedRFC3339TimeEncoder(12),
+			Timestamp: `"2020-02-29T13:37:42.123456789Z"`,
+		},
+		"unix seconds": {
+			Encoder:   jlo.UnixSecondsTimeEncoder,
+			Timestamp: `1582983462.1234567`,
+		},
+		"unix milliseconds": {
+			Encoder:   jlo.UnixMillisTimeEncoder,
+			Timestamp: `1582983462123`,
+		},
+		"unix nanoseconds": {
+			Encoder:   jlo.UnixNanosTimeEncoder,
+			Timestamp: `1582983462123456789`,
+		},
+		"layout": {
+			Encoder:   jlo.LayoutTimeEncoder("02.01.2006 15:04:05 \"MST\""),
+			Timestamp: `"29.02.2020 13:37:42 \"UTC\""`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+			l := jlo.NewLogger(buf)
+			l.SetClock(jlo.NewFrozenClock(clockTestTime))
+			l.SetTimeEncoder(test.Encoder)
+
+			l.WithField("@request_id", "e44c2a9").Infof("I'm real")
+
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"@message":    "I'm real",
+				"@level":      "info",
+				"@request_id": "e44c2a9",
+				"@timestamp":  %s
+			}`, test.Timestamp), buf.String())
+		})
+	}
+}
+
+func Test_Logger_SetTimeEncoder_WithTimePrecisionAndLocation(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer(nil)
+	l := jlo.NewLogger(buf)
+	l.SetClock(jlo.NewFrozenClock(clockTestTime))
+	l.SetTimePrecision(time.Millisecond)
+	l.SetTimeLocation(time.FixedZone("CET", 60*60))
+	l.SetTimeEncoder(jlo.FixedRFC3339TimeEncoder(6))
+
+	l.Infof("I'm real")
+	assert.Contains(t, buf.String(), `"@timestamp":"2020-02-29T14:37:42.123000+01:00"`)
+}